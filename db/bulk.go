@@ -0,0 +1,221 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RowIterator returns the next row of a bulk insert with one value per column.
+// it returns io.EOF after the last row
+type RowIterator func() ([]any, error)
+
+// RowsFromSlice returns an iterator over rows
+func RowsFromSlice(rows [][]any) RowIterator {
+	i := 0
+	return func() ([]any, error) {
+		if i >= len(rows) {
+			return nil, io.EOF
+		}
+		i++
+		return rows[i-1], nil
+	}
+}
+
+// BulkInsertOptions configures BulkInsertWithOptions
+type BulkInsertOptions struct {
+	// BatchSize is the maximum number of rows per INSERT statement and the interval in which
+	// Progress is called. it defaults to 1000 and is lowered to stay within the placeholder limit
+	BatchSize int
+
+	// Progress is called with the number of rows sent so far after every batch and at the end
+	Progress func(inserted int64)
+}
+
+// bulkInserter is implemented by dialects with a faster way to insert many rows than
+// batched INSERT statements
+type bulkInserter interface {
+	BulkInsert(ctx context.Context, conn GotoolsDb, table string, columns []string, rows RowIterator) (int64, error)
+}
+
+// placeholderLimiter is implemented by dialects which limit the number of parameters of a statement
+type placeholderLimiter interface {
+	MaxPlaceholders() int
+}
+
+// fallback for dialects which do not report a limit
+const defaultMaxPlaceholders = 999
+
+// BulkInsert inserts all rows of the iterator into table. see BulkInsertWithOptions
+func (mdb *Db) BulkInsert(ctx context.Context, table string, columns []string, rows RowIterator) (int64, error) {
+	return mdb.BulkInsertWithOptions(ctx, table, columns, rows, BulkInsertOptions{})
+}
+
+// BulkInsertWithOptions inserts all rows of the iterator into table using the fastest strategy
+// of the dialect: COPY FROM STDIN for postgres, the bulk copy protocol for mssql and batched
+// multi row INSERT statements inside of a transaction otherwise. the number of inserted rows is returned
+func (mdb *Db) BulkInsertWithOptions(ctx context.Context, table string, columns []string, rows RowIterator, options BulkInsertOptions) (int64, error) {
+	dialect, err := mdb.Dialect()
+	if err != nil {
+		return 0, err
+	}
+
+	if len(columns) == 0 {
+		return 0, fmt.Errorf("bulk insert into %s requires at least one column", table)
+	}
+
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	next := checkedRows(rows, len(columns))
+
+	if inserter, ok := dialect.(bulkInserter); ok {
+		var sent int64
+		counted := func() ([]any, error) {
+			values, err := next()
+			if err == nil {
+				sent++
+				if options.Progress != nil && sent%int64(batchSize) == 0 {
+					options.Progress(sent)
+				}
+			}
+			return values, err
+		}
+
+		inserted, err := inserter.BulkInsert(ctx, mdb.DbObj, table, columns, counted)
+		if err != nil {
+			return 0, fmt.Errorf("bulk insert into %s failed: %w", table, err)
+		}
+		if options.Progress != nil && sent%int64(batchSize) != 0 {
+			options.Progress(sent)
+		}
+
+		return inserted, nil
+	}
+
+	var inserted int64
+	err = mdb.WithTx(ctx, nil, func(tx DBOrTx) error {
+		var batchErr error
+		inserted, batchErr = insertBatches(ctx, tx, dialect, table, columns, next, batchSize, options.Progress)
+		return batchErr
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return inserted, nil
+}
+
+// insertBatches inserts all rows with multi row INSERT statements of up to batchSize rows
+// which is lowered to stay within the placeholder limit of the dialect
+func insertBatches(ctx context.Context, tx DBOrTx, dialect Dialect, table string, columns []string, next RowIterator, batchSize int, progress func(inserted int64)) (int64, error) {
+	maxPlaceholders := dialectMaxPlaceholders(dialect)
+	if maxRows := maxPlaceholders / len(columns); maxRows < batchSize {
+		batchSize = maxRows
+	}
+	if batchSize == 0 {
+		return 0, fmt.Errorf("bulk insert into %s: %d columns exceed the placeholder limit of %d", table, len(columns), maxPlaceholders)
+	}
+
+	var inserted int64
+	batch := make([][]any, 0, batchSize)
+	for {
+		values, err := next()
+		if err != nil && err != io.EOF {
+			return inserted, err
+		}
+
+		if err == nil {
+			batch = append(batch, values)
+		}
+
+		if len(batch) == batchSize || (err == io.EOF && len(batch) > 0) {
+			query, args := multiRowInsertQuery(dialect, table, columns, batch)
+			if _, execErr := tx.ExecContext(ctx, query, args...); execErr != nil {
+				return inserted, fmt.Errorf("bulk insert into %s failed after %d rows: %w", table, inserted, execErr)
+			}
+
+			inserted += int64(len(batch))
+			batch = batch[:0]
+
+			if progress != nil {
+				progress(inserted)
+			}
+		}
+
+		if err == io.EOF {
+			return inserted, nil
+		}
+	}
+}
+
+func dialectMaxPlaceholders(dialect Dialect) int {
+	if limiter, ok := dialect.(placeholderLimiter); ok {
+		return limiter.MaxPlaceholders()
+	}
+	return defaultMaxPlaceholders
+}
+
+// checkedRows rejects rows whose length does not match the columns
+func checkedRows(rows RowIterator, columnCount int) RowIterator {
+	n := 0
+	return func() ([]any, error) {
+		values, err := rows()
+		if err != nil {
+			return nil, err
+		}
+
+		n++
+		if len(values) != columnCount {
+			return nil, fmt.Errorf("row %d has %d values, expected %d", n, len(values), columnCount)
+		}
+
+		return values, nil
+	}
+}
+
+// multiRowInsertQuery creates one INSERT statement for all rows of batch
+func multiRowInsertQuery(dialect Dialect, table string, columns []string, batch [][]any) (string, []any) {
+	values, args := valuesList(dialect.Placeholder(), batch)
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s",
+		dialect.QuoteIdentifier(table),
+		strings.Join(quoteIdentifiers(dialect, columns), ", "),
+		values,
+	), args
+}
+
+// valuesList creates the row list of a VALUES clause like ($1, $2), ($3, $4) and returns the flattened values
+func valuesList(style PlaceholderStyle, batch [][]any) (string, []any) {
+	var sb strings.Builder
+	args := make([]any, 0, len(batch)*len(batch[0]))
+
+	for i, row := range batch {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+
+		sb.WriteByte('(')
+		for j, value := range row {
+			if j > 0 {
+				sb.WriteString(", ")
+			}
+			args = append(args, value)
+			sb.WriteString(style.Placeholder(len(args)))
+		}
+		sb.WriteByte(')')
+	}
+
+	return sb.String(), args
+}
+
+func quoteIdentifiers(dialect Dialect, names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = dialect.QuoteIdentifier(name)
+	}
+	return quoted
+}
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestBulkInsertBatches(t *testing.T) {
+	d := setupTxTestTable(t)
+
+	var statements int
+	d.AddHook(Hook{After: func(ctx context.Context, event *QueryEvent) {
+		if event.Operation == OpExec {
+			statements++
+		}
+	}})
+
+	rows := make([][]any, 25)
+	for i := range rows {
+		rows[i] = []any{string(rune('a' + i))}
+	}
+
+	var progress []int64
+	inserted, err := d.BulkInsertWithOptions(context.Background(), "items", []string{"name"}, RowsFromSlice(rows), BulkInsertOptions{
+		BatchSize: 10,
+		Progress: func(n int64) {
+			progress = append(progress, n)
+		},
+	})
+	if err != nil {
+		t.Fatalf("BulkInsert failed: %v", err)
+	}
+
+	if inserted != 25 || statements != 3 {
+		t.Errorf("Expected 25 rows in 3 statements, got %d rows in %d statements", inserted, statements)
+	}
+
+	if len(progress) != 3 || progress[0] != 10 || progress[2] != 25 {
+		t.Errorf("Expected progress 10 20 25, got %v", progress)
+	}
+
+	if count := countItems(t, d); count != 25 {
+		t.Errorf("Expected 25 items, got %d", count)
+	}
+}
+
+func TestBulkInsertRollsBackOnError(t *testing.T) {
+	d := setupTxTestTable(t)
+
+	iteratorErr := errors.New("source failed")
+	n := 0
+	rows := func() ([]any, error) {
+		n++
+		if n > 5 {
+			return nil, iteratorErr
+		}
+		return []any{"x"}, nil
+	}
+
+	_, err := d.BulkInsertWithOptions(context.Background(), "items", []string{"name"}, rows, BulkInsertOptions{BatchSize: 2})
+	if !errors.Is(err, iteratorErr) {
+		t.Fatalf("Expected the iterator error, got %v", err)
+	}
+
+	if _, err := d.BulkInsert(context.Background(), "items", []string{"name"}, RowsFromSlice([][]any{{"a", "b"}})); err == nil {
+		t.Error("Expected an error for a row with too many values")
+	}
+
+	if count := countItems(t, d); count != 0 {
+		t.Errorf("Expected the failed inserts to be rolled back, got %d items", count)
+	}
+}
+
+func TestMultiRowInsertQuery(t *testing.T) {
+	dialect, _ := GetDialect("postgres")
+	query, args := multiRowInsertQuery(dialect, "users", []string{"id", "name"}, [][]any{{1, "a"}, {2, "b"}})
+
+	expected := `INSERT INTO "users" ("id", "name") VALUES ($1, $2), ($3, $4)`
+	if query != expected || len(args) != 4 {
+		t.Errorf("Expected %s with 4 args, got %s with %v", expected, query, args)
+	}
+}
+
+func TestRowsFromSlice(t *testing.T) {
+	next := RowsFromSlice([][]any{{1}})
+	if row, err := next(); err != nil || row[0] != 1 {
+		t.Errorf("Expected the first row, got %v %v", row, err)
+	}
+	if _, err := next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+// Command dbgen generates Go structs for the tables of a database or of a json file with migrations.
+//
+//	dbgen -url postgres://user:pw@localhost:5432/app -out models/models.go -db -json
+//	dbgen -schema schema.json -dialect mysql -out models/models.go -null-types
+//
+// the schema file contains a json array of db.Migration
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/MathiasMantai/gotools/db"
+	"github.com/MathiasMantai/gotools/db/codegen"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "dbgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string) error {
+	flags := flag.NewFlagSet("dbgen", flag.ContinueOnError)
+	dbURL := flags.String("url", "", "url of the database to introspect")
+	schemaFile := flags.String("schema", "", "json file with migrations, used instead of -url")
+	dialect := flags.String("dialect", "", "database type of the types in -schema: "+strings.Join(db.Dialects(), ", "))
+	out := flags.String("out", "", "go file to write")
+	packageName := flags.String("package", "", "package name, defaults to the directory name of -out made a valid identifier")
+	tables := flags.String("tables", "", "comma separated tables to generate, defaults to all")
+	jsonTags := flags.Bool("json", false, "add json tags")
+	dbTags := flags.Bool("db", false, "add db tags")
+	nullTypes := flags.Bool("null-types", false, "use sql.Null* types instead of pointers for nullable columns")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *out == "" {
+		return fmt.Errorf("-out is required")
+	}
+
+	if (*dbURL == "") == (*schemaFile == "") {
+		return fmt.Errorf("exactly one of -url and -schema is required")
+	}
+
+	dbType, migrations, err := loadMigrations(*dbURL, *schemaFile, *dialect)
+	if err != nil {
+		return err
+	}
+
+	if *tables != "" {
+		selected := strings.Split(*tables, ",")
+		migrations = slices.DeleteFunc(migrations, func(migration db.Migration) bool {
+			return !slices.Contains(selected, migration.TableName)
+		})
+	}
+
+	if *packageName == "" {
+		absOut, err := filepath.Abs(*out)
+		if err != nil {
+			return err
+		}
+		*packageName = codegen.PackageName(filepath.Base(filepath.Dir(absOut)))
+	}
+
+	code, err := db.GenerateStructs(dbType, migrations, codegen.Options{
+		Package:   *packageName,
+		JSONTags:  *jsonTags,
+		DbTags:    *dbTags,
+		NullTypes: *nullTypes,
+		Generator: "dbgen",
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(*out), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(*out, code, 0644)
+}
+
+func loadMigrations(dbURL string, schemaFile string, dialect string) (string, []db.Migration, error) {
+	if schemaFile != "" {
+		if dialect == "" {
+			return "", nil, fmt.Errorf("-dialect is required with -schema")
+		}
+
+		data, err := os.ReadFile(schemaFile)
+		if err != nil {
+			return "", nil, err
+		}
+
+		var migrations []db.Migration
+		if err := json.Unmarshal(data, &migrations); err != nil {
+			return "", nil, fmt.Errorf("parsing %s failed: %w", schemaFile, err)
+		}
+
+		return dialect, migrations, nil
+	}
+
+	var conn db.Db
+	if err := conn.ConnectURL(dbURL); err != nil {
+		return "", nil, err
+	}
+	defer conn.DbObj.DB().Close()
+
+	migrations, err := conn.Introspect(context.Background())
+	if err != nil {
+		return "", nil, err
+	}
+
+	return conn.DbType, migrations, nil
+}
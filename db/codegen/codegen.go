@@ -0,0 +1,250 @@
+// Package codegen renders Go structs for database tables
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Table is a table to generate a struct for
+type Table struct {
+	Name    string
+	Columns []Column
+}
+
+// Column is a column of a table. GoType is the type used if the column is not nullable,
+// for example int64, time.Time or json.RawMessage
+type Column struct {
+	Name     string
+	GoType   string
+	Nullable bool
+}
+
+// Options configures Generate
+type Options struct {
+	// Package is the package clause of the generated file. it defaults to models.
+	// PackageName turns a directory name into a valid one
+	Package string
+
+	// JSONTags adds json tags with the column names
+	JSONTags bool
+
+	// DbTags adds db tags with the column names as used by db.Select
+	DbTags bool
+
+	// NullTypes uses the types of database/sql like sql.NullString for nullable columns
+	// where one exists. otherwise nullable columns become pointers
+	NullTypes bool
+
+	// Generator is named in the "Code generated" header. it defaults to codegen
+	Generator string
+}
+
+// packages referenced by the types of a column
+var typeImports = map[string]string{
+	"time.": "time",
+	"sql.":  "database/sql",
+	"json.": "encoding/json",
+}
+
+// types of database/sql replacing pointers with NullTypes
+var nullTypes = map[string]string{
+	"string":    "sql.NullString",
+	"int64":     "sql.NullInt64",
+	"int32":     "sql.NullInt32",
+	"int16":     "sql.NullInt16",
+	"uint8":     "sql.NullByte",
+	"float64":   "sql.NullFloat64",
+	"bool":      "sql.NullBool",
+	"time.Time": "sql.NullTime",
+}
+
+// Generate returns a gofmt'd Go file with one struct per table
+func Generate(tables []Table, options Options) ([]byte, error) {
+	packageName := options.Package
+	if packageName == "" {
+		packageName = "models"
+	}
+
+	if !token.IsIdentifier(packageName) {
+		return nil, fmt.Errorf("invalid package name %q", packageName)
+	}
+
+	generator := options.Generator
+	if generator == "" {
+		generator = "codegen"
+	}
+
+	imports := map[string]bool{}
+	structTables := map[string]string{}
+	var body bytes.Buffer
+
+	for i, table := range tables {
+		if i > 0 {
+			body.WriteString("\n")
+		}
+
+		structName := StructName(table.Name)
+		if other, ok := structTables[structName]; ok {
+			return nil, fmt.Errorf("tables %s and %s both become struct %s", other, table.Name, structName)
+		}
+		structTables[structName] = table.Name
+
+		fieldColumns := map[string]string{}
+		for _, column := range table.Columns {
+			fieldName := FieldName(column.Name)
+			if other, ok := fieldColumns[fieldName]; ok {
+				return nil, fmt.Errorf("table %s: columns %s and %s both become field %s", table.Name, other, column.Name, fieldName)
+			}
+			fieldColumns[fieldName] = column.Name
+		}
+
+		fmt.Fprintf(&body, "// %s is a row of the table %s\n", structName, table.Name)
+		fmt.Fprintf(&body, "type %s struct {\n", structName)
+
+		for _, column := range table.Columns {
+			goType := fieldType(column, options.NullTypes)
+			for prefix, path := range typeImports {
+				if strings.Contains(goType, prefix) {
+					imports[path] = true
+				}
+			}
+
+			fmt.Fprintf(&body, "\t%s %s%s\n", FieldName(column.Name), goType, fieldTags(column, options))
+		}
+
+		body.WriteString("}\n")
+	}
+
+	var file bytes.Buffer
+	fmt.Fprintf(&file, "// Code generated by %s. DO NOT EDIT.\n\n", generator)
+	fmt.Fprintf(&file, "package %s\n\n", packageName)
+
+	if len(imports) == 1 {
+		for path := range imports {
+			fmt.Fprintf(&file, "import %q\n\n", path)
+		}
+	} else if len(imports) > 1 {
+		paths := make([]string, 0, len(imports))
+		for path := range imports {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		file.WriteString("import (\n")
+		for _, path := range paths {
+			fmt.Fprintf(&file, "\t%q\n", path)
+		}
+		file.WriteString(")\n\n")
+	}
+
+	file.Write(body.Bytes())
+
+	formatted, err := format.Source(file.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code failed: %w", err)
+	}
+
+	return formatted, nil
+}
+
+func fieldType(column Column, useNullTypes bool) string {
+	goType := column.GoType
+	if goType == "" {
+		goType = "any"
+	}
+
+	// slices and interfaces can already hold nil
+	if !column.Nullable || strings.HasPrefix(goType, "[]") || goType == "any" || goType == "json.RawMessage" {
+		return goType
+	}
+
+	if nullType, ok := nullTypes[goType]; ok && useNullTypes {
+		return nullType
+	}
+
+	return "*" + goType
+}
+
+func fieldTags(column Column, options Options) string {
+	var tags []string
+	if options.DbTags {
+		tags = append(tags, fmt.Sprintf("db:%q", column.Name))
+	}
+	if options.JSONTags {
+		tags = append(tags, fmt.Sprintf("json:%q", column.Name))
+	}
+
+	if len(tags) == 0 {
+		return ""
+	}
+
+	return " `" + strings.Join(tags, " ") + "`"
+}
+
+// common initialisms written in upper case like golint expects them
+var initialisms = map[string]bool{
+	"ACL": true, "API": true, "CSV": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "SQL": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// PackageName converts a directory name like my-models into a valid package name like mymodels.
+// names starting with a digit or being a keyword get the prefix pkg and an empty result becomes models
+func PackageName(dir string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(dir) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_') {
+			sb.WriteRune(r)
+		}
+	}
+
+	name := sb.String()
+	switch {
+	case strings.Trim(name, "_") == "":
+		return "models"
+	case unicode.IsDigit(rune(name[0])) || token.IsKeyword(name):
+		return "pkg" + name
+	default:
+		return name
+	}
+}
+
+// StructName converts a table name like order_items into an exported Go name like OrderItems
+func StructName(table string) string {
+	return exportedName(table)
+}
+
+// FieldName converts a column name like user_id into an exported Go name like UserID
+func FieldName(column string) string {
+	return exportedName(column)
+}
+
+func exportedName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var sb strings.Builder
+	for _, part := range parts {
+		if initialisms[strings.ToUpper(part)] {
+			sb.WriteString(strings.ToUpper(part))
+			continue
+		}
+
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+
+	result := sb.String()
+	if result == "" || unicode.IsDigit([]rune(result)[0]) {
+		result = "X" + result
+	}
+
+	return result
+}
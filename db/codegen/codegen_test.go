@@ -0,0 +1,117 @@
+package codegen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	tables := []Table{
+		{
+			Name: "order_items",
+			Columns: []Column{
+				{Name: "id", GoType: "int64"},
+				{Name: "user_id", GoType: "int64", Nullable: true},
+				{Name: "created_at", GoType: "time.Time", Nullable: true},
+				{Name: "payload", GoType: "[]byte", Nullable: true},
+			},
+		},
+	}
+
+	code, err := Generate(tables, Options{Package: "models", DbTags: true, JSONTags: true})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, expected := range []string{
+		"// Code generated by codegen. DO NOT EDIT.",
+		"package models",
+		"import \"time\"",
+		"type OrderItems struct {",
+		"ID        int64      `db:\"id\" json:\"id\"`",
+		"UserID    *int64     `db:\"user_id\" json:\"user_id\"`",
+		"CreatedAt *time.Time `db:\"created_at\" json:\"created_at\"`",
+		"Payload   []byte     `db:\"payload\" json:\"payload\"`",
+	} {
+		if !strings.Contains(string(code), expected) {
+			t.Errorf("Expected %q in\n%s", expected, code)
+		}
+	}
+}
+
+func TestGenerateNullTypes(t *testing.T) {
+	tables := []Table{
+		{
+			Name: "users",
+			Columns: []Column{
+				{Name: "name", GoType: "string", Nullable: true},
+				{Name: "score", GoType: "float32", Nullable: true},
+			},
+		},
+	}
+
+	code, err := Generate(tables, Options{NullTypes: true})
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	for _, expected := range []string{"package models", "\"database/sql\"", "Name  sql.NullString", "Score *float32"} {
+		if !strings.Contains(string(code), expected) {
+			t.Errorf("Expected %q in\n%s", expected, code)
+		}
+	}
+
+	if strings.Contains(string(code), "\"time\"") {
+		t.Errorf("Expected no unused imports in\n%s", code)
+	}
+}
+
+func TestFieldName(t *testing.T) {
+	tests := map[string]string{
+		"user_id":    "UserID",
+		"api_url":    "APIURL",
+		"first-name": "FirstName",
+		"2fa":        "X2fa",
+		"createdAt":  "CreatedAt",
+	}
+
+	for column, expected := range tests {
+		if result := FieldName(column); result != expected {
+			t.Errorf("FieldName(%q): expected %s, got %s", column, expected, result)
+		}
+	}
+}
+
+func TestGenerateNameCollisions(t *testing.T) {
+	_, err := Generate([]Table{{Name: "users", Columns: []Column{{Name: "id", GoType: "int64"}, {Name: "ID", GoType: "int64"}}}}, Options{})
+	if err == nil || !strings.Contains(err.Error(), "columns id and ID both become field ID") {
+		t.Errorf("Expected a field collision error, got %v", err)
+	}
+
+	_, err = Generate([]Table{{Name: "order_items"}, {Name: "OrderItems"}}, Options{})
+	if err == nil || !strings.Contains(err.Error(), "both become struct OrderItems") {
+		t.Errorf("Expected a struct collision error, got %v", err)
+	}
+
+	if _, err := Generate(nil, Options{Package: "my-models"}); err == nil {
+		t.Errorf("Expected an error for an invalid package name")
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	tests := map[string]string{
+		"models":    "models",
+		"my-models": "mymodels",
+		"Models":    "models",
+		"2024":      "pkg2024",
+		"type":      "pkgtype",
+		"---":       "models",
+		"db_models": "db_models",
+	}
+
+	for dir, expected := range tests {
+		if result := PackageName(dir); result != expected {
+			t.Errorf("PackageName(%q): expected %s, got %s", dir, expected, result)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	defaultRetryBackoff    = 500 * time.Millisecond
+	defaultMaxRetryBackoff = 30 * time.Second
+)
+
+// ConnectError is returned by Connect if the database could not be reached
+type ConnectError struct {
+	DbType string
+
+	// attempt that failed last, starting at 1
+	Attempt int
+
+	// total number of attempts that were allowed
+	MaxAttempts int
+
+	Err error
+}
+
+func (e *ConnectError) Error() string {
+	return fmt.Sprintf("connecting to %s database failed at attempt %d of %d: %v", e.DbType, e.Attempt, e.MaxAttempts, e.Err)
+}
+
+func (e *ConnectError) Unwrap() error {
+	return e.Err
+}
+
+// pingWithRetry calls ping until it succeeds or the retries configured in options are used up
+func pingWithRetry(ctx context.Context, dbType string, ping func(context.Context) error, options DbConnectOptions) error {
+	if options.ConnectTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.ConnectTimeout)
+		defer cancel()
+	}
+
+	backoff := options.RetryBackoff
+	if backoff <= 0 {
+		backoff = defaultRetryBackoff
+	}
+
+	maxBackoff := options.MaxRetryBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxRetryBackoff
+	}
+
+	maxAttempts := options.ConnectRetries + 1
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := ping(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= maxAttempts || ctx.Err() != nil {
+			return &ConnectError{DbType: dbType, Attempt: attempt, MaxAttempts: maxAttempts, Err: err}
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return &ConnectError{DbType: dbType, Attempt: attempt, MaxAttempts: maxAttempts, Err: errors.Join(err, ctx.Err())}
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPingWithRetrySucceedsAfterFailures(t *testing.T) {
+	calls := 0
+	ping := func(ctx context.Context) error {
+		calls++
+		if calls < 3 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}
+
+	err := pingWithRetry(context.Background(), "postgres", ping, DbConnectOptions{
+		ConnectRetries: 5,
+		RetryBackoff:   time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Expected connection to succeed, got %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("Expected 3 pings, got %d", calls)
+	}
+}
+
+func TestPingWithRetryReturnsConnectError(t *testing.T) {
+	refused := errors.New("connection refused")
+	calls := 0
+	ping := func(ctx context.Context) error {
+		calls++
+		return refused
+	}
+
+	err := pingWithRetry(context.Background(), "mysql", ping, DbConnectOptions{
+		ConnectRetries: 2,
+		RetryBackoff:   time.Millisecond,
+	})
+
+	var connectErr *ConnectError
+	if !errors.As(err, &connectErr) {
+		t.Fatalf("Expected a *ConnectError, got %v", err)
+	}
+
+	if connectErr.Attempt != 3 || connectErr.MaxAttempts != 3 || connectErr.DbType != "mysql" {
+		t.Errorf("Unexpected error details: %+v", connectErr)
+	}
+
+	if !errors.Is(err, refused) {
+		t.Errorf("Expected error to wrap the ping error, got %v", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("Expected 3 pings, got %d", calls)
+	}
+}
+
+func TestPingWithRetryStopsAtDeadline(t *testing.T) {
+	ping := func(ctx context.Context) error {
+		return errors.New("connection refused")
+	}
+
+	start := time.Now()
+	err := pingWithRetry(context.Background(), "mssql", ping, DbConnectOptions{
+		ConnectRetries: 100,
+		RetryBackoff:   20 * time.Millisecond,
+		ConnectTimeout: 50 * time.Millisecond,
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected deadline exceeded, got %v", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected retries to stop at the deadline, took %v", elapsed)
+	}
+}
+
+func TestConnectPingsDatabase(t *testing.T) {
+	d := getTestDb(t, DbConnectOptions{ConnectRetries: 1})
+
+	if err := d.PingContext(context.Background()); err != nil {
+		t.Errorf("Expected database to be reachable, got %v", err)
+	}
+}
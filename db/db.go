@@ -3,12 +3,8 @@ package db
 import (
 	"context"
 	"database/sql"
-	"errors"
-	"fmt"
-	"github.com/MathiasMantai/gotools/db/mssql"
-	"github.com/MathiasMantai/gotools/db/mysql"
-	"github.com/MathiasMantai/gotools/db/postgres"
-	"github.com/MathiasMantai/gotools/db/sqlite"
+	"github.com/MathiasMantai/gotools/db/dblog"
+	"time"
 )
 
 type GotoolsDb interface {
@@ -16,11 +12,22 @@ type GotoolsDb interface {
 	QueryRow(string, ...any) *sql.Row
 	Exec(string, ...any) (sql.Result, error)
 	BeginTx(context.Context, *sql.TxOptions) (*sql.Tx, error)
+	QueryContext(context.Context, string, ...any) (*sql.Rows, error)
+	QueryRowContext(context.Context, string, ...any) *sql.Row
+	ExecContext(context.Context, string, ...any) (sql.Result, error)
+	PingContext(context.Context) error
+	DB() *sql.DB
 }
 
 type Db struct {
 	DbObj  GotoolsDb
 	DbType string
+
+	// observe every statement executed through the Db. see AddHook
+	Hooks []Hook
+
+	// read replicas. see AddReplica
+	replicas *replicaSet
 }
 
 type DbConnectOptions struct {
@@ -33,62 +40,193 @@ type DbConnectOptions struct {
 	User     string
 	Pw       string
 	Protocol string
+
+	// number of additional pings after the first one failed. the wait time between
+	// attempts starts at RetryBackoff (default 500ms) and doubles up to MaxRetryBackoff (default 30s)
+	ConnectRetries  int
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+
+	// deadline for establishing the connection including all retries. zero means no deadline
+	ConnectTimeout time.Duration
+
+	// connection pool settings. a zero value keeps the database/sql default
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	TLS TLSOptions
+
+	Sqlite SqliteOptions
+
+	// free-form driver parameters which are added to the connection string of the dialect
+	Params map[string]string
+
+	// receives the messages of the connection and of CreateMigrations. nil discards them,
+	// a *slog.Logger or dblog.FromLogger can be used
+	Logger dblog.Logger
+}
+
+// SqliteOptions configures sqlite connections, see sqlite.Options
+type SqliteOptions struct {
+	// InMemory keeps the database in memory, Database names it and may be empty without SharedCache.
+	// without SharedCache the pool is pinned to one connection and pool options are rejected
+	InMemory    bool
+	SharedCache bool
+	ReadOnly    bool
+
+	// enforce foreign key constraints, sqlite ignores them by default
+	ForeignKeys bool
+
+	// zero values keep the driver defaults
+	BusyTimeout time.Duration
+	Synchronous string
+	CacheSize   int
+	JournalMode string
+
+	// executed without the PRAGMA keyword on every new connection, for example "temp_store = MEMORY"
+	Pragmas []string
 }
 
+// tls modes following the naming of the postgres sslmode parameter
+const (
+	TLSDisable    = "disable"
+	TLSPrefer     = "prefer"
+	TLSRequire    = "require"
+	TLSVerifyCA   = "verify-ca"
+	TLSVerifyFull = "verify-full"
+)
+
+// TLSOptions configures encrypted connections. an empty Mode keeps the driver default
+type TLSOptions struct {
+	Mode string
+
+	// path to a pem encoded ca certificate used to verify the server
+	CAFile string
+
+	// client certificate and key for mutual tls
+	CertFile string
+	KeyFile  string
+
+	// host name expected in the server certificate if it differs from Server
+	ServerName string
+}
+
+// applyPoolOptions configures the connection pool of conn with the pool settings of options
+func applyPoolOptions(conn *sql.DB, options DbConnectOptions) {
+	if options.MaxOpenConns != 0 {
+		conn.SetMaxOpenConns(options.MaxOpenConns)
+	}
+
+	if options.MaxIdleConns != 0 {
+		conn.SetMaxIdleConns(options.MaxIdleConns)
+	}
+
+	if options.ConnMaxLifetime != 0 {
+		conn.SetConnMaxLifetime(options.ConnMaxLifetime)
+	}
+
+	if options.ConnMaxIdleTime != 0 {
+		conn.SetConnMaxIdleTime(options.ConnMaxIdleTime)
+	}
+}
+
+// Connect opens a connection to the database and verifies it with a ping.
+// see ConnectContext for the retry behaviour
 func (d *Db) Connect(dbType string, options DbConnectOptions) error {
+	return d.ConnectContext(context.Background(), dbType, options)
+}
+
+// ConnectContext opens a connection to the database and pings it until it is reachable,
+// ConnectRetries are used up or ConnectTimeout or ctx expire. a failed verification returns a *ConnectError
+func (d *Db) ConnectContext(ctx context.Context, dbType string, options DbConnectOptions) error {
 	d.DbType = dbType
-	var err error
-
-	switch dbType {
-	case "mysql":
-		d.DbObj, err = mysql.Connect(
-			options.Server,
-			options.Port,
-			options.Database,
-			options.User,
-			options.Pw,
-			options.Protocol,
-		)
-	case "mssql":
-		d.DbObj, err = mssql.Connect(
-			options.Server,
-			options.Port,
-			options.Database,
-			options.User,
-			options.Pw,
-		)
-	case "sqlite":
-		d.DbObj, err = sqlite.Connect(
-			options.Database,
-		)
-	case "postgres":
-		d.DbObj, err = postgres.Connect(
-			options.Server,
-			options.Port,
-			options.Database,
-			options.User,
-			options.Pw,
-		)
 
+	dialect, err := GetDialect(dbType)
+	if err != nil {
+		return err
 	}
 
-	return err
+	d.DbObj, err = dialect.Connect(options)
+
+	if err != nil {
+		return err
+	}
+
+	if d.DbObj != nil {
+		applyPoolOptions(d.DbObj.DB(), options)
+
+		err = pingWithRetry(ctx, dbType, d.DbObj.PingContext, options)
+		if err != nil {
+			d.DbObj.DB().Close()
+			d.DbObj = nil
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stats returns statistics of the underlying connection pool
+func (mdb *Db) Stats() sql.DBStats {
+	return mdb.DbObj.DB().Stats()
 }
 
 func (mdb *Db) BeginTx(ctx context.Context, options *sql.TxOptions) (*sql.Tx, error) {
-	return mdb.DbObj.BeginTx(ctx, options)
+	var tx *sql.Tx
+	err := runHooks(ctx, mdb.Hooks, OpBegin, "BEGIN", nil, func(ctx context.Context, event *QueryEvent) error {
+		var err error
+		tx, err = mdb.DbObj.BeginTx(ctx, options)
+		return err
+	})
+
+	return tx, err
 }
 
 func (mdb *Db) Exec(query string, args ...any) (sql.Result, error) {
-	return mdb.DbObj.Exec(query, args...)
+	return mdb.ExecContext(context.Background(), query, args...)
 }
 
 func (mdb *Db) Query(query string, args ...any) (*sql.Rows, error) {
-	return mdb.DbObj.Query(query, args...)
+	return mdb.QueryContext(context.Background(), query, args...)
 }
 
 func (mdb *Db) QueryRow(query string, args ...any) *sql.Row {
-	return mdb.DbObj.QueryRow(query, args...)
+	return mdb.QueryRowContext(context.Background(), query, args...)
+}
+
+func (mdb *Db) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return hookedExec(ctx, mdb.Hooks, mdb.DbObj.ExecContext, query, args)
+}
+
+// QueryContext runs a query on a replica if replicas are configured. if the replica cannot be
+// reached the query is retried once on the primary. see Primary
+func (mdb *Db) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	reader, r := mdb.reader(ctx)
+	rows, err := hookedQuery(ctx, mdb.Hooks, reader.QueryContext, query, args)
+	if r.report(err) {
+		rows, err = hookedQuery(ctx, mdb.Hooks, mdb.DbObj.QueryContext, query, args)
+	}
+
+	return rows, err
+}
+
+// QueryRowContext runs a query on a replica if replicas are configured. if the replica cannot be
+// reached the query is retried once on the primary. see Primary
+func (mdb *Db) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	reader, r := mdb.reader(ctx)
+	row := hookedQueryRow(ctx, mdb.Hooks, reader.QueryRowContext, query, args)
+	if r.report(row.Err()) {
+		row = hookedQueryRow(ctx, mdb.Hooks, mdb.DbObj.QueryRowContext, query, args)
+	}
+
+	return row
+}
+
+// PingContext verifies that the database is still reachable
+func (mdb *Db) PingContext(ctx context.Context) error {
+	return mdb.DbObj.PingContext(ctx)
 }
 
 /*****************
@@ -100,6 +238,9 @@ type Migration struct {
 	Description string
 	Fields      []MigrationField
 	ForeignKeys []ForeignKey
+
+	// Indexes are filled by Introspect. primary keys are described by the fields instead
+	Indexes []Index
 }
 
 type MigrationField struct {
@@ -117,6 +258,12 @@ type ForeignKey struct {
 	ReferenceColumn string
 }
 
+type Index struct {
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
 type MigrationRunner interface {
 	Run() error
 	IsMigrationApplied(string) (bool, error)
@@ -125,151 +272,13 @@ type MigrationRunner interface {
 	LogMigration(string, string) error
 }
 
+// CreateMigrations creates the tables of migrations which have not been applied yet
+// using the dialect registered for the database type
 func CreateMigrations(db *Db, migrations []Migration) error {
-	switch db.DbType {
-	case "mssql":
-		{
-			if mssqlDb, ok := db.DbObj.(*mssql.MssqlDb); ok {
-				runner := mssql.CreateMigrationRunner(mssqlDb)
-				runner.Migrations = []mssql.Migration{}
-
-				//add migrations
-				for _, migration := range migrations {
-					var realMigration mssql.Migration
-
-					realMigration.TableName = migration.TableName
-					realMigration.Description = migration.Description
-					realMigration.Fields = []mssql.MigrationField{}
-					realMigration.ForeignKeys = []mssql.ForeignKey{}
-
-					// fields
-					for _, field := range migration.Fields {
-						var realField mssql.MigrationField
-						realField.Name = field.Name
-						realField.DataType = field.DataType
-						realField.Nullable = field.Nullable
-						realField.PrimaryKey = field.PrimaryKey
-						realField.AutoIncrement = field.AutoIncrement
-						realMigration.Fields = append(realMigration.Fields, realField)
-					}
-
-					//foreign keys
-					for _, fKey := range migration.ForeignKeys {
-						var realFkey mssql.ForeignKey
-						realFkey.Name = fKey.Name
-						realFkey.Column = fKey.Column
-						realFkey.ReferenceTable = fKey.ReferenceTable
-						realFkey.ReferenceColumn = fKey.ReferenceColumn
-						realMigration.ForeignKeys = append(realMigration.ForeignKeys, realFkey)
-					}
-					runner.Migrations = append(runner.Migrations, realMigration)
-				}
-
-				err := runner.Run()
-				if err != nil {
-					return err
-				}
-
-				return nil
-			} else {
-				return errors.New("database type supported but connection to database not established")
-			}
-		}
-	case "mysql":
-		{
-			if mysqlDb, ok := db.DbObj.(*mysql.MySqlDb); ok {
-				runner := mysql.CreateMigrationRunner(mysqlDb)
-
-				//add migrations
-				for _, migration := range migrations {
-					var realMigration mysql.Migration
-
-					realMigration.TableName = migration.TableName
-					realMigration.Description = migration.Description
-					realMigration.Fields = []mysql.MigrationField{}
-					realMigration.ForeignKeys = []mysql.ForeignKey{}
-
-					// fields
-					for _, field := range migration.Fields {
-						var realField mysql.MigrationField
-						realField.Name = field.Name
-						realField.DataType = field.DataType
-						realField.Nullable = field.Nullable
-						realField.PrimaryKey = field.PrimaryKey
-						realField.AutoIncrement = field.AutoIncrement
-						realMigration.Fields = append(realMigration.Fields, realField)
-					}
-
-					//foreign keys
-					for _, fKey := range migration.ForeignKeys {
-						var realFkey mysql.ForeignKey
-						realFkey.Name = fKey.Name
-						realFkey.Column = fKey.Column
-						realFkey.ReferenceTable = fKey.ReferenceTable
-						realFkey.ReferenceColumn = fKey.ReferenceColumn
-						realMigration.ForeignKeys = append(realMigration.ForeignKeys, realFkey)
-					}
-
-					runner.Migrations = append(runner.Migrations, realMigration)
-				}
-
-				err := runner.Run()
-				if err != nil {
-					return err
-				}
-
-				return nil
-			} else {
-				return errors.New("database type supported but connection to database not established")
-			}
-		}
-	case "sqlite":
-		{
-			if sqliteDb, ok := db.DbObj.(*sqlite.SqliteDb); ok {
-				runner := sqlite.MigrationRunner{}
-				runner.Db = sqliteDb
-				for _, migration := range migrations {
-					var realMigration sqlite.Migration
-
-					realMigration.TableName = migration.TableName
-					realMigration.Description = migration.Description
-					realMigration.Fields = []sqlite.MigrationField{}
-					realMigration.ForeignKeys = []sqlite.ForeignKey{}
-
-					// fields
-					for _, field := range migration.Fields {
-						var realField sqlite.MigrationField
-						realField.Name = field.Name
-						realField.DataType = field.DataType
-						realField.Nullable = field.Nullable
-						realField.PrimaryKey = field.PrimaryKey
-						realField.AutoIncrement = field.AutoIncrement
-						realMigration.Fields = append(realMigration.Fields, realField)
-					}
-
-					//foreign keys
-					for _, fKey := range migration.ForeignKeys {
-						var realFkey sqlite.ForeignKey
-						realFkey.Name = fKey.Name
-						realFkey.Column = fKey.Column
-						realFkey.ReferenceTable = fKey.ReferenceTable
-						realFkey.ReferenceColumn = fKey.ReferenceColumn
-						realMigration.ForeignKeys = append(realMigration.ForeignKeys, realFkey)
-					}
-
-					runner.Migrations = append(runner.Migrations, realMigration)
-				}
-
-				err := runner.Run()
-				if err != nil {
-					return err
-				}
-				return nil
-			} else {
-				return errors.New("database type supported but connection to database not established")
-			}
-		}
-	default:
-		return fmt.Errorf("unsupported Database type %v", db.DbType)
+	dialect, err := GetDialect(db.DbType)
+	if err != nil {
+		return err
 	}
+
+	return dialect.RunMigrations(db.DbObj, migrations)
 }
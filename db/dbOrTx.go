@@ -1,12 +1,21 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 )
 
-// interface so that
+// interface so that *sql.Tx and Db can be used interchangeably
 type DBOrTx interface {
 	Exec(query string, args ...any) (sql.Result, error)
 	QueryRow(query string, args ...any) *sql.Row
 	Query(query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
 }
+
+var (
+	_ DBOrTx = (*sql.Tx)(nil)
+	_ DBOrTx = (*Db)(nil)
+)
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func getTestDb(t *testing.T, options DbConnectOptions) *Db {
+	t.Helper()
+
+	if options.Database == "" {
+		options.Database = filepath.Join(t.TempDir(), "test.db")
+	}
+
+	var d Db
+	if err := d.Connect("sqlite", options); err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	t.Cleanup(func() {
+		d.DbObj.DB().Close()
+	})
+
+	return &d
+}
+
+func TestConnectAppliesPoolOptions(t *testing.T) {
+	d := getTestDb(t, DbConnectOptions{
+		MaxOpenConns:    3,
+		MaxIdleConns:    2,
+		ConnMaxLifetime: time.Minute,
+		ConnMaxIdleTime: time.Second * 30,
+	})
+
+	stats := d.Stats()
+	if stats.MaxOpenConnections != 3 {
+		t.Errorf("Expected MaxOpenConnections 3, got %d", stats.MaxOpenConnections)
+	}
+}
+
+func TestConnectSqliteOptions(t *testing.T) {
+	d := getTestDb(t, DbConnectOptions{
+		Database: "sqlite_options",
+		Sqlite: SqliteOptions{
+			InMemory:    true,
+			SharedCache: true,
+			ForeignKeys: true,
+			Pragmas:     []string{"user_version = 3"},
+		},
+	})
+
+	var foreignKeys, userVersion int
+	if err := d.QueryRow("PRAGMA foreign_keys").Scan(&foreignKeys); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.QueryRow("PRAGMA user_version").Scan(&userVersion); err != nil {
+		t.Fatal(err)
+	}
+
+	if foreignKeys != 1 || userVersion != 3 {
+		t.Errorf("Expected foreign_keys 1 and user_version 3, got %d and %d", foreignKeys, userVersion)
+	}
+}
+
+func TestConnectRejectsPoolOptionsForPrivateMemorySqlite(t *testing.T) {
+	var d Db
+	// a negative MaxOpenConns means unlimited connections
+	for _, maxOpenConns := range []int{4, -1} {
+		d = Db{}
+		err := d.Connect("sqlite", DbConnectOptions{
+			MaxOpenConns: maxOpenConns,
+			Sqlite:       SqliteOptions{InMemory: true},
+		})
+		if err == nil {
+			d.DbObj.DB().Close()
+			t.Fatalf("Expected MaxOpenConns %d to be rejected for a private in memory database", maxOpenConns)
+		}
+	}
+
+	d = Db{}
+	err := d.Connect("sqlite", DbConnectOptions{MaxOpenConns: 1, Sqlite: SqliteOptions{InMemory: true}})
+	if err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	defer d.DbObj.DB().Close()
+
+	if _, err := d.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Exec("INSERT INTO items DEFAULT VALUES"); err != nil {
+		t.Errorf("Expected the table to exist for the next statement: %v", err)
+	}
+	if stats := d.Stats(); stats.MaxOpenConnections != 1 {
+		t.Errorf("Expected MaxOpenConnections 1, got %d", stats.MaxOpenConnections)
+	}
+}
@@ -0,0 +1,100 @@
+// Package dblog is the logging interface of the database connectors and migration runners.
+// nothing is logged unless a Logger is set
+package dblog
+
+import (
+	"fmt"
+	"github.com/MathiasMantai/gotools/logger"
+	"reflect"
+	"strings"
+)
+
+// Logger receives messages with alternating key value pairs like log/slog.
+// a *slog.Logger can be used directly
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// Nop discards every message
+var Nop Logger = nop{}
+
+type nop struct{}
+
+func (nop) Debug(msg string, args ...any) {}
+func (nop) Info(msg string, args ...any)  {}
+func (nop) Warn(msg string, args ...any)  {}
+func (nop) Error(msg string, args ...any) {}
+
+// Or returns the first logger that is not nil, or Nop if there is none.
+// a typed nil like a nil *slog.Logger counts as nil
+func Or(loggers ...Logger) Logger {
+	for _, l := range loggers {
+		if !isNil(l) {
+			return l
+		}
+	}
+
+	return Nop
+}
+
+func isNil(l Logger) bool {
+	if l == nil {
+		return true
+	}
+
+	v := reflect.ValueOf(l)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Func, reflect.Chan, reflect.Slice:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+// FromLogger writes to a logger.Logger. the key value pairs are appended to the message like key=value.
+// logger.Logger has no debug level, so debug messages are dropped unless debug is true
+func FromLogger(l *logger.Logger, debug bool) Logger {
+	return &loggerAdapter{logger: l, debug: debug}
+}
+
+type loggerAdapter struct {
+	logger *logger.Logger
+	debug  bool
+}
+
+func (a *loggerAdapter) Debug(msg string, args ...any) {
+	if a.debug {
+		a.logger.LogMessage(format(msg, args))
+	}
+}
+
+func (a *loggerAdapter) Info(msg string, args ...any) {
+	a.logger.LogMessage(format(msg, args))
+}
+
+func (a *loggerAdapter) Warn(msg string, args ...any) {
+	a.logger.LogWarning(format(msg, args))
+}
+
+func (a *loggerAdapter) Error(msg string, args ...any) {
+	a.logger.LogError(format(msg, args))
+}
+
+// format appends the key value pairs to msg. a key without value is written as !BADKEY like slog does
+func format(msg string, args []any) string {
+	var sb strings.Builder
+	sb.WriteString(msg)
+
+	for i := 0; i < len(args); i += 2 {
+		if i+1 >= len(args) {
+			fmt.Fprintf(&sb, " !BADKEY=%v", args[i])
+			break
+		}
+		fmt.Fprintf(&sb, " %v=%v", args[i], args[i+1])
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,51 @@
+package dblog
+
+import (
+	"log/slog"
+	"testing"
+)
+
+// slog is used without an adapter
+var _ Logger = slog.Default()
+
+func TestOr(t *testing.T) {
+	if Or() != Nop {
+		t.Errorf("expected Nop without loggers")
+	}
+
+	if Or(nil, nil) != Nop {
+		t.Errorf("expected Nop for nil loggers")
+	}
+
+	var unset *slog.Logger
+	if Or(unset) != Nop {
+		t.Errorf("expected Nop for a typed nil logger")
+	}
+
+	l := slog.Default()
+	if Or(unset, l) != Logger(l) {
+		t.Errorf("expected a typed nil logger to be skipped")
+	}
+	if Or(nil, l) != Logger(l) {
+		t.Errorf("expected the first logger that is not nil")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		msg      string
+		args     []any
+		expected string
+	}{
+		{"migration applied", nil, "migration applied"},
+		{"migration applied", []any{"table", "users"}, "migration applied table=users"},
+		{"query", []any{"rows", 3, "error", "none"}, "query rows=3 error=none"},
+		{"query", []any{"rows", 3, "odd"}, "query rows=3 !BADKEY=odd"},
+	}
+
+	for _, test := range tests {
+		if actual := format(test.msg, test.args); actual != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Package dbtest provides throwaway sqlite databases for tests of code built on db.Db
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"github.com/MathiasMantai/gotools/db"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+// Options configures the database created by NewWithOptions
+type Options struct {
+	// Migrations are applied with db.CreateMigrations before the database is handed out
+	Migrations []db.Migration
+
+	// InMemory keeps the database in memory instead of a file in a temporary directory
+	InMemory bool
+
+	// Params are appended to the sqlite connection string
+	Params map[string]string
+}
+
+var memoryDbCount atomic.Uint64
+
+// New returns a fresh sqlite database with migrations applied.
+// the database is closed and removed when the test finishes
+func New(t testing.TB, migrations ...db.Migration) *db.Db {
+	t.Helper()
+
+	return NewWithOptions(t, Options{Migrations: migrations})
+}
+
+// NewWithOptions returns a fresh sqlite database configured by options.
+// the database is closed and removed when the test finishes
+func NewWithOptions(t testing.TB, options Options) *db.Db {
+	t.Helper()
+
+	params := map[string]string{}
+	for key, value := range options.Params {
+		params[key] = value
+	}
+
+	connectOptions := db.DbConnectOptions{Params: params}
+	if options.InMemory {
+		// a named shared cache database lives as long as one connection of the pool is open
+		connectOptions.Database = fmt.Sprintf("dbtest_%d", memoryDbCount.Add(1))
+		connectOptions.Sqlite = db.SqliteOptions{InMemory: true, SharedCache: true}
+	} else {
+		connectOptions.Database = filepath.Join(t.TempDir(), "test.db")
+	}
+
+	var d db.Db
+	if err := d.Connect("sqlite", connectOptions); err != nil {
+		t.Fatalf("dbtest: connecting to sqlite failed: %v", err)
+	}
+	t.Cleanup(func() {
+		d.DbObj.DB().Close()
+	})
+
+	if len(options.Migrations) > 0 {
+		if err := db.CreateMigrations(&d, options.Migrations); err != nil {
+			t.Fatalf("dbtest: applying migrations failed: %v", err)
+		}
+	}
+
+	return &d
+}
+
+// Tx begins a transaction on d which is rolled back when the test finishes,
+// so rows written through it never leak into other tests sharing d.
+// while the transaction is open sqlite blocks writes of other connections, so
+// the test should use the returned Tx exclusively
+func Tx(t testing.TB, d *db.Db) *db.Tx {
+	t.Helper()
+
+	tx, err := d.Begin(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("dbtest: %v", err)
+	}
+	t.Cleanup(func() {
+		tx.Tx.Rollback()
+	})
+
+	return tx
+}
+
+// NewTx combines New and Tx: it returns a transaction on a fresh database with migrations applied
+func NewTx(t testing.TB, migrations ...db.Migration) *db.Tx {
+	t.Helper()
+
+	return Tx(t, New(t, migrations...))
+}
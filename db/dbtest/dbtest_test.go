@@ -0,0 +1,74 @@
+package dbtest
+
+import (
+	"github.com/MathiasMantai/gotools/db"
+	"testing"
+)
+
+var testMigrations = []db.Migration{
+	{
+		TableName:   "users",
+		Description: "create users",
+		Fields: []db.MigrationField{
+			{Name: "id", DataType: "INTEGER", PrimaryKey: true, AutoIncrement: true},
+			{Name: "name", DataType: "TEXT"},
+		},
+	},
+}
+
+func countUsers(t *testing.T, dbOrTx db.DBOrTx) int {
+	t.Helper()
+
+	var count int
+	if err := dbOrTx.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil {
+		t.Fatalf("Counting users failed: %v", err)
+	}
+
+	return count
+}
+
+func TestNewAppliesMigrations(t *testing.T) {
+	for _, inMemory := range []bool{false, true} {
+		d := NewWithOptions(t, Options{Migrations: testMigrations, InMemory: inMemory})
+
+		if _, err := d.Exec("INSERT INTO users (name) VALUES (?)", "a"); err != nil {
+			t.Fatalf("Inserting into migrated table failed (in memory: %v): %v", inMemory, err)
+		}
+
+		if count := countUsers(t, d); count != 1 {
+			t.Errorf("Expected 1 user, got %d", count)
+		}
+	}
+}
+
+func TestNewIsolatesDatabases(t *testing.T) {
+	first := NewWithOptions(t, Options{Migrations: testMigrations, InMemory: true})
+	second := NewWithOptions(t, Options{Migrations: testMigrations, InMemory: true})
+
+	if _, err := first.Exec("INSERT INTO users (name) VALUES (?)", "a"); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	if count := countUsers(t, second); count != 0 {
+		t.Errorf("Expected the second database to be empty, got %d users", count)
+	}
+}
+
+func TestTxRollsBackAfterTest(t *testing.T) {
+	d := New(t, testMigrations...)
+
+	t.Run("writes", func(t *testing.T) {
+		tx := Tx(t, d)
+		if _, err := tx.Exec("INSERT INTO users (name) VALUES (?)", "a"); err != nil {
+			t.Fatalf("Insert failed: %v", err)
+		}
+
+		if count := countUsers(t, tx); count != 1 {
+			t.Errorf("Expected 1 user inside the transaction, got %d", count)
+		}
+	})
+
+	if count := countUsers(t, d); count != 0 {
+		t.Errorf("Expected the insert to be rolled back, got %d users", count)
+	}
+}
@@ -0,0 +1,105 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Dialect implements everything that differs between database engines.
+// new dialects are made available to Db.Connect and CreateMigrations with Register
+type Dialect interface {
+	// Connect opens a connection. pool settings, retries and the initial ping are handled by Db.Connect
+	Connect(options DbConnectOptions) (GotoolsDb, error)
+
+	// Placeholder returns the style of positional query parameters
+	Placeholder() PlaceholderStyle
+
+	// QuoteIdentifier quotes a table or column name
+	QuoteIdentifier(name string) string
+
+	// SavepointQueries returns the statements to create, release and roll back to a savepoint.
+	// release may be empty if the engine has no such statement
+	SavepointQueries(name string) (create string, release string, rollback string)
+
+	// CreateTableQueries returns the statements creating the table of migration including its foreign keys
+	CreateTableQueries(migration Migration) []string
+
+	// RunMigrations applies migrations which have not been logged yet on a connection opened by Connect
+	RunMigrations(conn GotoolsDb, migrations []Migration) error
+}
+
+var (
+	dialectsMu sync.RWMutex
+	dialects   = map[string]Dialect{}
+)
+
+func init() {
+	Register("mysql", mysqlDialect{})
+	Register("mssql", mssqlDialect{})
+	Register("postgres", postgresDialect{})
+	Register("sqlite", sqliteDialect{})
+}
+
+// Register makes a dialect available under name. like sql.Register it panics
+// if dialect is nil or the name is already taken
+func Register(name string, dialect Dialect) {
+	dialectsMu.Lock()
+	defer dialectsMu.Unlock()
+
+	if dialect == nil {
+		panic("db: Register dialect is nil")
+	}
+
+	if _, exists := dialects[name]; exists {
+		panic("db: Register called twice for dialect " + name)
+	}
+
+	dialects[name] = dialect
+}
+
+// GetDialect returns the dialect registered under name
+func GetDialect(name string) (Dialect, error) {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+
+	dialect, ok := dialects[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database type %q (registered: %v)", name, registeredDialects())
+	}
+
+	return dialect, nil
+}
+
+// Dialects returns the sorted names of all registered dialects
+func Dialects() []string {
+	dialectsMu.RLock()
+	defer dialectsMu.RUnlock()
+
+	return registeredDialects()
+}
+
+func registeredDialects() []string {
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Dialect returns the dialect of the connected database type
+func (mdb *Db) Dialect() (Dialect, error) {
+	return GetDialect(mdb.DbType)
+}
+
+// placeholderStyle falls back to ? for unknown database types
+func placeholderStyle(dbType string) PlaceholderStyle {
+	dialect, err := GetDialect(dbType)
+	if err != nil {
+		return PlaceholderQuestion
+	}
+
+	return dialect.Placeholder()
+}
@@ -0,0 +1,199 @@
+package db
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/MathiasMantai/gotools/db/mssql"
+	"strings"
+	"time"
+)
+
+type mssqlDialect struct{}
+
+func (mssqlDialect) Connect(options DbConnectOptions) (GotoolsDb, error) {
+	return mssql.ConnectWithConnData(mssql.DbConnData{
+		Server:   options.Server,
+		Port:     options.Port,
+		Database: options.Database,
+		User:     options.User,
+		Pw:       options.Pw,
+		TLS:      mssql.TLSConfig(options.TLS),
+		Params:   options.Params,
+		Logger:   options.Logger,
+	})
+}
+
+func (mssqlDialect) Placeholder() PlaceholderStyle {
+	return PlaceholderAtP
+}
+
+func (mssqlDialect) QuoteIdentifier(name string) string {
+	return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+}
+
+// mssql has no statement to release a savepoint
+func (mssqlDialect) SavepointQueries(name string) (string, string, string) {
+	return "SAVE TRANSACTION " + name, "", "ROLLBACK TRANSACTION " + name
+}
+
+// BulkInsert uses the bulk copy protocol
+func (mssqlDialect) BulkInsert(ctx context.Context, conn GotoolsDb, table string, columns []string, rows RowIterator) (int64, error) {
+	mssqlDb, ok := conn.(*mssql.MssqlDb)
+	if !ok {
+		return 0, errors.New("database type supported but connection to database not established")
+	}
+
+	return mssqlDb.CopyIn(ctx, table, columns, rows)
+}
+
+// InsertReturningQuery uses the OUTPUT clause
+func (d mssqlDialect) InsertReturningQuery(table string, columns []string, values string, returning string) string {
+	return fmt.Sprintf("INSERT INTO %s (%s) OUTPUT INSERTED.%s VALUES %s",
+		d.QuoteIdentifier(table),
+		strings.Join(quoteIdentifiers(d, columns), ", "),
+		d.QuoteIdentifier(returning),
+		values,
+	)
+}
+
+// MaxPlaceholders is the parameter limit of a request
+func (mssqlDialect) MaxPlaceholders() int {
+	return 2100
+}
+
+// Literal uses unicode strings, binary constants and bits for booleans. timestamps are written
+// in UTC in the ISO 8601 format which datetime2 and datetimeoffset accept independent of the language
+func (mssqlDialect) Literal(value any) string {
+	switch v := value.(type) {
+	case string:
+		return "N'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case []byte:
+		return "0x" + hex.EncodeToString(v)
+	case time.Time:
+		return "'" + v.UTC().Format("2006-01-02T15:04:05.9999999") + "'"
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	default:
+		return standardLiteral(value)
+	}
+}
+
+// LimitClause uses OFFSET ... FETCH which has to follow an ORDER BY clause
+func (mssqlDialect) LimitClause(limit int, offset int) string {
+	return fmt.Sprintf("OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", offset, limit)
+}
+
+// MigrationTableQuery checks OBJECT_ID since CREATE TABLE has no IF NOT EXISTS
+func (d mssqlDialect) MigrationTableQuery(table string) string {
+	return fmt.Sprintf(
+		"IF OBJECT_ID(N'%s', N'U') IS NULL CREATE TABLE %s (version BIGINT NOT NULL PRIMARY KEY, name NVARCHAR(255) NOT NULL, checksum VARCHAR(64) NOT NULL, applied_at DATETIME2 NOT NULL)",
+		strings.ReplaceAll(d.QuoteIdentifier(table), "'", "''"),
+		d.QuoteIdentifier(table),
+	)
+}
+
+// SplitScript splits at lines only containing GO like sqlcmd, for example to create a procedure
+// which has to be the first statement of its batch
+func (mssqlDialect) SplitScript(script string) ([]string, error) {
+	var batches []string
+	var batch strings.Builder
+
+	for _, line := range strings.SplitAfter(script, "\n") {
+		if strings.EqualFold(strings.TrimSpace(line), "GO") {
+			batches = append(batches, batch.String())
+			batch.Reset()
+			continue
+		}
+		batch.WriteString(line)
+	}
+
+	return append(batches, batch.String()), nil
+}
+
+// UpsertQuery uses MERGE. HOLDLOCK prevents concurrent merges from inserting the same key twice
+func (d mssqlDialect) UpsertQuery(table string, columns []string, conflictColumns []string, updateColumns []string, values string) string {
+	conditions := make([]string, len(conflictColumns))
+	for i, column := range conflictColumns {
+		conditions[i] = "target." + d.QuoteIdentifier(column) + " = source." + d.QuoteIdentifier(column)
+	}
+
+	quoted := quoteIdentifiers(d, columns)
+	sourceColumns := make([]string, len(columns))
+	for i, column := range quoted {
+		sourceColumns[i] = "source." + column
+	}
+
+	query := fmt.Sprintf("MERGE INTO %s WITH (HOLDLOCK) AS target USING (VALUES %s) AS source (%s) ON %s",
+		d.QuoteIdentifier(table),
+		values,
+		strings.Join(quoted, ", "),
+		strings.Join(conditions, " AND "),
+	)
+
+	if len(updateColumns) > 0 {
+		assignments := make([]string, len(updateColumns))
+		for i, column := range updateColumns {
+			assignments[i] = "target." + d.QuoteIdentifier(column) + " = source." + d.QuoteIdentifier(column)
+		}
+		query += " WHEN MATCHED THEN UPDATE SET " + strings.Join(assignments, ", ")
+	}
+
+	return query + fmt.Sprintf(" WHEN NOT MATCHED THEN INSERT (%s) VALUES (%s);",
+		strings.Join(quoted, ", "),
+		strings.Join(sourceColumns, ", "),
+	)
+}
+
+// IdentityInsertQueries allows explicit values for the identity column of table
+func (d mssqlDialect) IdentityInsertQueries(table string, column string) ([]string, []string) {
+	quoted := d.QuoteIdentifier(table)
+	return []string{"SET IDENTITY_INSERT " + quoted + " ON"}, []string{"SET IDENTITY_INSERT " + quoted + " OFF"}
+}
+
+// foreign keys are generated for the dbo schema since the default schema is only known on a connection
+func (mssqlDialect) CreateTableQueries(migration Migration) []string {
+	realMigration := toMssqlMigration(migration)
+	return append([]string{realMigration.CreateQuery()}, realMigration.CreateForeignKeyQueries("dbo")...)
+}
+
+func (mssqlDialect) RunMigrations(conn GotoolsDb, migrations []Migration) error {
+	mssqlDb, ok := conn.(*mssql.MssqlDb)
+	if !ok {
+		return errors.New("database type supported but connection to database not established")
+	}
+
+	runner := mssql.CreateMigrationRunner(mssqlDb)
+	for _, migration := range migrations {
+		runner.Migrations = append(runner.Migrations, toMssqlMigration(migration))
+	}
+
+	return runner.Run()
+}
+
+func (mssqlDialect) GoType(dataType string) string {
+	return mssql.MapMssqlTypeToGo(dataType)
+}
+
+func toMssqlMigration(migration Migration) mssql.Migration {
+	realMigration := mssql.Migration{
+		TableName:   migration.TableName,
+		Description: migration.Description,
+		Fields:      []mssql.MigrationField{},
+		ForeignKeys: []mssql.ForeignKey{},
+	}
+
+	for _, field := range migration.Fields {
+		realMigration.Fields = append(realMigration.Fields, mssql.MigrationField(field))
+	}
+
+	for _, fKey := range migration.ForeignKeys {
+		realMigration.ForeignKeys = append(realMigration.ForeignKeys, mssql.ForeignKey(fKey))
+	}
+
+	return realMigration
+}
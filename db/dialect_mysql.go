@@ -0,0 +1,125 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MathiasMantai/gotools/db/mysql"
+	"strings"
+	"time"
+)
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Connect(options DbConnectOptions) (GotoolsDb, error) {
+	return mysql.ConnectWithConnData(mysql.DbConnData{
+		Server:   options.Server,
+		Port:     options.Port,
+		Database: options.Database,
+		User:     options.User,
+		Pw:       options.Pw,
+		Protocol: options.Protocol,
+		TLS:      mysql.TLSConfig(options.TLS),
+		Params:   options.Params,
+		Logger:   options.Logger,
+	})
+}
+
+func (mysqlDialect) Placeholder() PlaceholderStyle {
+	return PlaceholderQuestion
+}
+
+func (mysqlDialect) QuoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (mysqlDialect) SavepointQueries(name string) (string, string, string) {
+	return "SAVEPOINT " + name, "RELEASE SAVEPOINT " + name, "ROLLBACK TO SAVEPOINT " + name
+}
+
+// MaxPlaceholders is the parameter limit of a prepared statement
+func (mysqlDialect) MaxPlaceholders() int {
+	return 65535
+}
+
+// Literal escapes backslashes which start escape sequences in mysql strings. timestamps are
+// written in UTC like the driver sends them
+func (mysqlDialect) Literal(value any) string {
+	switch v := value.(type) {
+	case string:
+		return "'" + strings.NewReplacer(`\`, `\\`, "'", "''").Replace(v) + "'"
+	case time.Time:
+		return "'" + v.UTC().Format("2006-01-02 15:04:05.999999") + "'"
+	default:
+		return standardLiteral(value)
+	}
+}
+
+// UpsertQuery uses INSERT ... ON DUPLICATE KEY UPDATE, which matches any unique key of the table
+func (d mysqlDialect) UpsertQuery(table string, columns []string, conflictColumns []string, updateColumns []string, values string) string {
+	assignments := make([]string, len(updateColumns))
+	for i, column := range updateColumns {
+		assignments[i] = d.QuoteIdentifier(column) + " = VALUES(" + d.QuoteIdentifier(column) + ")"
+	}
+
+	// assigning a key column to itself keeps existing rows unchanged
+	if len(assignments) == 0 {
+		assignments = append(assignments, d.QuoteIdentifier(conflictColumns[0])+" = "+d.QuoteIdentifier(conflictColumns[0]))
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s ON DUPLICATE KEY UPDATE %s",
+		d.QuoteIdentifier(table),
+		strings.Join(quoteIdentifiers(d, columns), ", "),
+		values,
+		strings.Join(assignments, ", "),
+	)
+}
+
+// MigrationTableQuery uses DATETIME since TIMESTAMP columns may update themselves depending on the server settings
+func (d mysqlDialect) MigrationTableQuery(table string) string {
+	return fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, checksum VARCHAR(64) NOT NULL, applied_at DATETIME(6) NOT NULL)",
+		d.QuoteIdentifier(table),
+	)
+}
+
+func (mysqlDialect) CreateTableQueries(migration Migration) []string {
+	realMigration := toMysqlMigration(migration)
+	return append([]string{realMigration.CreateQuery()}, realMigration.CreateForeignKeyQueries()...)
+}
+
+func (mysqlDialect) RunMigrations(conn GotoolsDb, migrations []Migration) error {
+	mysqlDb, ok := conn.(*mysql.MySqlDb)
+	if !ok {
+		return errors.New("database type supported but connection to database not established")
+	}
+
+	runner := mysql.CreateMigrationRunner(mysqlDb)
+	for _, migration := range migrations {
+		runner.Migrations = append(runner.Migrations, toMysqlMigration(migration))
+	}
+
+	return runner.Run()
+}
+
+func (mysqlDialect) GoType(dataType string) string {
+	return mysql.MapMysqlTypeToGo(dataType)
+}
+
+func toMysqlMigration(migration Migration) mysql.Migration {
+	realMigration := mysql.Migration{
+		TableName:   migration.TableName,
+		Description: migration.Description,
+		Fields:      []mysql.MigrationField{},
+		ForeignKeys: []mysql.ForeignKey{},
+	}
+
+	for _, field := range migration.Fields {
+		realMigration.Fields = append(realMigration.Fields, mysql.MigrationField(field))
+	}
+
+	for _, fKey := range migration.ForeignKeys {
+		realMigration.ForeignKeys = append(realMigration.ForeignKeys, mysql.ForeignKey(fKey))
+	}
+
+	return realMigration
+}
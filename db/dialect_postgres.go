@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/MathiasMantai/gotools/db/postgres"
+	"strings"
+)
+
+type postgresDialect struct{}
+
+func (postgresDialect) Connect(options DbConnectOptions) (GotoolsDb, error) {
+	return postgres.ConnectWithConnData(postgres.DbConnData{
+		Server:   options.Server,
+		Port:     options.Port,
+		Database: options.Database,
+		User:     options.User,
+		Pw:       options.Pw,
+		TLS:      postgres.TLSConfig(options.TLS),
+		Params:   options.Params,
+		Logger:   options.Logger,
+	})
+}
+
+func (postgresDialect) Placeholder() PlaceholderStyle {
+	return PlaceholderDollar
+}
+
+func (postgresDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (postgresDialect) SavepointQueries(name string) (string, string, string) {
+	return "SAVEPOINT " + name, "RELEASE SAVEPOINT " + name, "ROLLBACK TO SAVEPOINT " + name
+}
+
+// BulkInsert uses COPY FROM STDIN
+func (postgresDialect) BulkInsert(ctx context.Context, conn GotoolsDb, table string, columns []string, rows RowIterator) (int64, error) {
+	pgDb, ok := conn.(*postgres.PgSqlDb)
+	if !ok {
+		return 0, errors.New("database type supported but connection to database not established")
+	}
+
+	return pgDb.CopyFrom(ctx, table, columns, rows)
+}
+
+// IdentityInsertQueries moves the sequence of a serial column past explicitly inserted values
+func (d postgresDialect) IdentityInsertQueries(table string, column string) ([]string, []string) {
+	return nil, []string{fmt.Sprintf(
+		"SELECT setval(pg_get_serial_sequence('%s', '%s'), COALESCE(MAX(%s), 0) + 1, false) FROM %s",
+		strings.ReplaceAll(d.QuoteIdentifier(table), "'", "''"),
+		strings.ReplaceAll(column, "'", "''"),
+		d.QuoteIdentifier(column),
+		d.QuoteIdentifier(table),
+	)}
+}
+
+// InsertReturningQuery uses INSERT ... RETURNING
+func (d postgresDialect) InsertReturningQuery(table string, columns []string, values string, returning string) string {
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s RETURNING %s",
+		d.QuoteIdentifier(table),
+		strings.Join(quoteIdentifiers(d, columns), ", "),
+		values,
+		d.QuoteIdentifier(returning),
+	)
+}
+
+// MaxPlaceholders is the parameter limit of the wire protocol
+func (postgresDialect) MaxPlaceholders() int {
+	return 65535
+}
+
+// Literal writes bytes in the hex format of bytea
+func (postgresDialect) Literal(value any) string {
+	if b, ok := value.([]byte); ok {
+		return `'\x` + hex.EncodeToString(b) + "'::bytea"
+	}
+
+	return standardLiteral(value)
+}
+
+// UpsertQuery uses INSERT ... ON CONFLICT DO UPDATE
+func (d postgresDialect) UpsertQuery(table string, columns []string, conflictColumns []string, updateColumns []string, values string) string {
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s ON CONFLICT (%s) ",
+		d.QuoteIdentifier(table),
+		strings.Join(quoteIdentifiers(d, columns), ", "),
+		values,
+		strings.Join(quoteIdentifiers(d, conflictColumns), ", "),
+	)
+
+	if len(updateColumns) == 0 {
+		return query + "DO NOTHING"
+	}
+
+	assignments := make([]string, len(updateColumns))
+	for i, column := range updateColumns {
+		assignments[i] = d.QuoteIdentifier(column) + " = EXCLUDED." + d.QuoteIdentifier(column)
+	}
+
+	return query + "DO UPDATE SET " + strings.Join(assignments, ", ")
+}
+
+func (postgresDialect) CreateTableQueries(migration Migration) []string {
+	realMigration := toPostgresMigration(migration)
+	return append([]string{realMigration.CreateQuery()}, realMigration.CreateForeignKeyQueries()...)
+}
+
+func (postgresDialect) RunMigrations(conn GotoolsDb, migrations []Migration) error {
+	pgDb, ok := conn.(*postgres.PgSqlDb)
+	if !ok {
+		return errors.New("database type supported but connection to database not established")
+	}
+
+	runner := postgres.CreateMigrationRunner(pgDb)
+	for _, migration := range migrations {
+		runner.Migrations = append(runner.Migrations, toPostgresMigration(migration))
+	}
+
+	return runner.Run()
+}
+
+func (postgresDialect) GoType(dataType string) string {
+	return postgres.MapPgTypeToGo(dataType)
+}
+
+func toPostgresMigration(migration Migration) postgres.Migration {
+	realMigration := postgres.Migration{
+		TableName:        migration.TableName,
+		Description:      migration.Description,
+		Fields:           []postgres.MigrationField{},
+		ForeignKeys:      []postgres.ForeignKey{},
+		NotNullByDefault: true,
+	}
+
+	for _, field := range migration.Fields {
+		realMigration.Fields = append(realMigration.Fields, postgres.MigrationField(field))
+	}
+
+	for _, fKey := range migration.ForeignKeys {
+		realMigration.ForeignKeys = append(realMigration.ForeignKeys, postgres.ForeignKey(fKey))
+	}
+
+	return realMigration
+}
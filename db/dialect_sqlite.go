@@ -0,0 +1,119 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"github.com/MathiasMantai/gotools/db/sqlite"
+	"strings"
+)
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Connect(options DbConnectOptions) (GotoolsDb, error) {
+	if options.TLS != (TLSOptions{}) {
+		return nil, errors.New("tls options are not supported for sqlite")
+	}
+
+	// a private in memory database lives in its only connection, more connections would each see an
+	// empty database and a closed connection drops it
+	if options.Sqlite.InMemory && !options.Sqlite.SharedCache &&
+		(options.MaxOpenConns != 0 && options.MaxOpenConns != 1 || options.MaxIdleConns < 0 || options.ConnMaxLifetime != 0 || options.ConnMaxIdleTime != 0) {
+		return nil, errors.New("pool options are not supported for private in memory sqlite databases, use SharedCache for more connections")
+	}
+
+	return sqlite.ConnectWithOptions(options.Database, sqlite.Options{
+		Params:      options.Params,
+		Logger:      options.Logger,
+		InMemory:    options.Sqlite.InMemory,
+		SharedCache: options.Sqlite.SharedCache,
+		ReadOnly:    options.Sqlite.ReadOnly,
+		ForeignKeys: options.Sqlite.ForeignKeys,
+		BusyTimeout: options.Sqlite.BusyTimeout,
+		Synchronous: options.Sqlite.Synchronous,
+		CacheSize:   options.Sqlite.CacheSize,
+		JournalMode: options.Sqlite.JournalMode,
+		Pragmas:     options.Sqlite.Pragmas,
+	})
+}
+
+func (sqliteDialect) Placeholder() PlaceholderStyle {
+	return PlaceholderQuestion
+}
+
+func (sqliteDialect) QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (sqliteDialect) SavepointQueries(name string) (string, string, string) {
+	return "SAVEPOINT " + name, "RELEASE SAVEPOINT " + name, "ROLLBACK TO SAVEPOINT " + name
+}
+
+// MaxPlaceholders is SQLITE_MAX_VARIABLE_NUMBER of the bundled sqlite
+func (sqliteDialect) MaxPlaceholders() int {
+	return 32766
+}
+
+// UpsertQuery uses INSERT ... ON CONFLICT DO UPDATE
+func (d sqliteDialect) UpsertQuery(table string, columns []string, conflictColumns []string, updateColumns []string, values string) string {
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s ON CONFLICT (%s) ",
+		d.QuoteIdentifier(table),
+		strings.Join(quoteIdentifiers(d, columns), ", "),
+		values,
+		strings.Join(quoteIdentifiers(d, conflictColumns), ", "),
+	)
+
+	if len(updateColumns) == 0 {
+		return query + "DO NOTHING"
+	}
+
+	assignments := make([]string, len(updateColumns))
+	for i, column := range updateColumns {
+		assignments[i] = d.QuoteIdentifier(column) + " = EXCLUDED." + d.QuoteIdentifier(column)
+	}
+
+	return query + "DO UPDATE SET " + strings.Join(assignments, ", ")
+}
+
+// sqlite declares foreign keys inside of the CREATE TABLE statement
+func (sqliteDialect) CreateTableQueries(migration Migration) []string {
+	realMigration := toSqliteMigration(migration)
+	return []string{realMigration.CreateQuery()}
+}
+
+func (sqliteDialect) RunMigrations(conn GotoolsDb, migrations []Migration) error {
+	sqliteDb, ok := conn.(*sqlite.SqliteDb)
+	if !ok {
+		return errors.New("database type supported but connection to database not established")
+	}
+
+	runner := sqlite.MigrationRunner{}
+	runner.Db = sqliteDb
+	for _, migration := range migrations {
+		runner.Migrations = append(runner.Migrations, toSqliteMigration(migration))
+	}
+
+	return runner.Run()
+}
+
+func (sqliteDialect) GoType(dataType string) string {
+	return sqlite.MapSqliteTypeToGo(dataType)
+}
+
+func toSqliteMigration(migration Migration) sqlite.Migration {
+	realMigration := sqlite.Migration{
+		TableName:   migration.TableName,
+		Description: migration.Description,
+		Fields:      []sqlite.MigrationField{},
+		ForeignKeys: []sqlite.ForeignKey{},
+	}
+
+	for _, field := range migration.Fields {
+		realMigration.Fields = append(realMigration.Fields, sqlite.MigrationField(field))
+	}
+
+	for _, fKey := range migration.ForeignKeys {
+		realMigration.ForeignKeys = append(realMigration.ForeignKeys, sqlite.ForeignKey(fKey))
+	}
+
+	return realMigration
+}
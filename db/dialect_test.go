@@ -0,0 +1,191 @@
+package db
+
+import (
+	"strings"
+	"testing"
+)
+
+type testDialect struct {
+	sqliteDialect
+}
+
+func (testDialect) QuoteIdentifier(name string) string {
+	return "<" + name + ">"
+}
+
+func TestConnectUnknownDialect(t *testing.T) {
+	var d Db
+	err := d.Connect("oracle", DbConnectOptions{})
+	if err == nil {
+		t.Fatal("Expected an error for an unregistered database type")
+	}
+
+	if !strings.Contains(err.Error(), "oracle") || !strings.Contains(err.Error(), "sqlite") {
+		t.Errorf("Expected the error to name the type and the registered dialects, got %v", err)
+	}
+
+	if err := CreateMigrations(&d, []Migration{}); err == nil {
+		t.Error("Expected an error creating migrations for an unregistered database type")
+	}
+}
+
+func TestRegisterDialect(t *testing.T) {
+	Register("test-sqlite", testDialect{})
+	defer func() {
+		dialectsMu.Lock()
+		delete(dialects, "test-sqlite")
+		dialectsMu.Unlock()
+	}()
+
+	var d Db
+	if err := d.Connect("test-sqlite", DbConnectOptions{Database: t.TempDir() + "/test.db"}); err != nil {
+		t.Fatalf("Connecting with a registered dialect failed: %v", err)
+	}
+	defer d.DbObj.DB().Close()
+
+	dialect, err := d.Dialect()
+	if err != nil {
+		t.Fatalf("Dialect failed: %v", err)
+	}
+
+	if quoted := dialect.QuoteIdentifier("users"); quoted != "<users>" {
+		t.Errorf("Expected the custom dialect, got %s", quoted)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected Register to panic for a duplicate name")
+		}
+	}()
+	Register("sqlite", testDialect{})
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"mysql":    "`we``ird`",
+		"mssql":    "[we]]ird]",
+		"postgres": `"we""ird"`,
+		"sqlite":   `"we""ird"`,
+	}
+
+	for dbType, expected := range tests {
+		dialect, err := GetDialect(dbType)
+		if err != nil {
+			t.Fatalf("GetDialect(%s) failed: %v", dbType, err)
+		}
+
+		if quoted := dialect.QuoteIdentifier(map[string]string{
+			"mysql":    "we`ird",
+			"mssql":    "we]ird",
+			"postgres": `we"ird`,
+			"sqlite":   `we"ird`,
+		}[dbType]); quoted != expected {
+			t.Errorf("%s: expected %s, got %s", dbType, expected, quoted)
+		}
+	}
+}
+
+func TestSavepointQueries(t *testing.T) {
+	dialect, _ := GetDialect("mssql")
+	create, release, rollback := dialect.SavepointQueries("sp")
+	if create != "SAVE TRANSACTION sp" || rollback != "ROLLBACK TRANSACTION sp" || release != "" {
+		t.Errorf("Unexpected mssql savepoint queries: %s, %s, %s", create, release, rollback)
+	}
+
+	dialect, _ = GetDialect("postgres")
+	create, release, _ = dialect.SavepointQueries("sp")
+	if create != "SAVEPOINT sp" || release != "RELEASE SAVEPOINT sp" {
+		t.Errorf("Unexpected postgres savepoint queries: %s, %s", create, release)
+	}
+}
+
+func TestCreateTableQueries(t *testing.T) {
+	migration := Migration{
+		TableName: "orders",
+		Fields: []MigrationField{
+			{Name: "id", DataType: "INT", AutoIncrement: true},
+			{Name: "user_id", DataType: "INT"},
+		},
+		ForeignKeys: []ForeignKey{
+			{Name: "fk_orders_user", Column: "user_id", ReferenceTable: "users", ReferenceColumn: "id"},
+		},
+	}
+
+	for _, dbType := range []string{"mysql", "mssql", "postgres"} {
+		dialect, _ := GetDialect(dbType)
+		queries := dialect.CreateTableQueries(migration)
+		if len(queries) != 2 || !strings.Contains(queries[0], "orders") || !strings.Contains(queries[1], "fk_orders_user") {
+			t.Errorf("%s: expected a create table and a foreign key query, got %v", dbType, queries)
+		}
+	}
+
+	dialect, _ := GetDialect("sqlite")
+	queries := dialect.CreateTableQueries(migration)
+	if len(queries) != 1 || !strings.Contains(queries[0], "REFERENCES users") {
+		t.Errorf("sqlite: expected an inline foreign key, got %v", queries)
+	}
+}
+
+func TestCreateMigrationsSqlite(t *testing.T) {
+	d := getTestDb(t, DbConnectOptions{})
+
+	migrations := []Migration{
+		{
+			TableName:   "users",
+			Description: "create users",
+			Fields: []MigrationField{
+				{Name: "id", DataType: "INTEGER", PrimaryKey: true, AutoIncrement: true},
+				{Name: "name", DataType: "TEXT"},
+			},
+		},
+	}
+
+	if err := CreateMigrations(d, migrations); err != nil {
+		t.Fatalf("CreateMigrations failed: %v", err)
+	}
+
+	// applied migrations are skipped
+	if err := CreateMigrations(d, migrations); err != nil {
+		t.Fatalf("Running migrations twice failed: %v", err)
+	}
+
+	if _, err := d.Exec("INSERT INTO users (name) VALUES (?)", "a"); err != nil {
+		t.Errorf("Inserting into migrated table failed: %v", err)
+	}
+}
+
+func TestIdentityInsertQueries(t *testing.T) {
+	dialect, _ := GetDialect("mssql")
+	before, after := dialect.(identityInserter).IdentityInsertQueries("users", "id")
+	if len(before) != 1 || before[0] != "SET IDENTITY_INSERT [users] ON" || len(after) != 1 || after[0] != "SET IDENTITY_INSERT [users] OFF" {
+		t.Errorf("Unexpected mssql identity queries: %v %v", before, after)
+	}
+
+	dialect, _ = GetDialect("postgres")
+	before, after = dialect.(identityInserter).IdentityInsertQueries("users", "id")
+	expected := `SELECT setval(pg_get_serial_sequence('"users"', 'id'), COALESCE(MAX("id"), 0) + 1, false) FROM "users"`
+	if len(before) != 0 || len(after) != 1 || after[0] != expected {
+		t.Errorf("Unexpected postgres identity queries: %v %v", before, after)
+	}
+
+	if _, ok := Dialect(sqliteDialect{}).(identityInserter); ok {
+		t.Error("Expected sqlite to insert explicit ids without extra queries")
+	}
+}
+
+func TestInsertReturningQuery(t *testing.T) {
+	dialect, _ := GetDialect("mssql")
+	query := dialect.(returningInserter).InsertReturningQuery("users", []string{"name"}, "(@p1)", "id")
+
+	expected := "INSERT INTO [users] ([name]) OUTPUT INSERTED.[id] VALUES (@p1)"
+	if query != expected {
+		t.Errorf("Expected %s, got %s", expected, query)
+	}
+
+	for _, dbType := range []string{"mysql", "sqlite"} {
+		dialect, _ := GetDialect(dbType)
+		if _, ok := dialect.(returningInserter); ok {
+			t.Errorf("Expected %s to read generated ids with LastInsertId", dbType)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"syscall"
+	"time"
+)
+
+// ErrorCategory is the driver independent kind of a database error
+type ErrorCategory int
+
+const (
+	// errors that match no other category, including nil
+	Unclassified ErrorCategory = iota
+	// a unique index or primary key already contains the value
+	UniqueViolation
+	// a referenced row does not exist or is still referenced
+	ForeignKeyViolation
+	// NULL was written into a NOT NULL column
+	NotNullViolation
+	// the transaction was chosen as deadlock victim
+	Deadlock
+	// a serializable or snapshot transaction conflicted with a concurrent one
+	SerializationFailure
+	// the database or a lock was held by another connection until a timeout, like SQLITE_BUSY
+	Busy
+	// a table or row is locked, like SQLITE_LOCKED
+	Locked
+	// the connection broke. the statement may or may not have been executed
+	ConnectionLost
+)
+
+var errorCategoryNames = map[ErrorCategory]string{
+	Unclassified:         "unclassified",
+	UniqueViolation:      "unique violation",
+	ForeignKeyViolation:  "foreign key violation",
+	NotNullViolation:     "not null violation",
+	Deadlock:             "deadlock",
+	SerializationFailure: "serialization failure",
+	Busy:                 "busy",
+	Locked:               "locked",
+	ConnectionLost:       "connection lost",
+}
+
+func (c ErrorCategory) String() string {
+	if name, ok := errorCategoryNames[c]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Transient reports if retrying the failed transaction can succeed
+func (c ErrorCategory) Transient() bool {
+	return c == Deadlock || c == SerializationFailure || c == Busy || c == Locked
+}
+
+// errorClassifier is implemented by dialects which know the error codes of their driver.
+// it returns Unclassified for errors of other drivers
+type errorClassifier interface {
+	ClassifyError(err error) ErrorCategory
+}
+
+// Classify maps an error returned by any of the registered drivers, wrapped or not, to its category
+func Classify(err error) ErrorCategory {
+	if err == nil {
+		return Unclassified
+	}
+
+	for _, name := range Dialects() {
+		dialect, dialectErr := GetDialect(name)
+		if dialectErr != nil {
+			continue
+		}
+		if classifier, ok := dialect.(errorClassifier); ok {
+			if category := classifier.ClassifyError(err); category != Unclassified {
+				return category
+			}
+		}
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return Unclassified
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone), errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE), errors.As(err, &netErr):
+		return ConnectionLost
+	}
+
+	return Unclassified
+}
+
+// IsTransient reports if err is a deadlock, serialization failure or lock timeout
+// after which the transaction can be retried
+func IsTransient(err error) bool {
+	return Classify(err).Transient()
+}
+
+// RetryPolicy retries operations which failed with a transient error. the zero value
+// makes 3 attempts waiting 50ms and 100ms in between
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts including the first one. it defaults to 3
+	MaxAttempts int
+
+	// Backoff is the wait time before the second attempt which doubles for every further attempt
+	// up to MaxBackoff. a random jitter of up to half of the wait time is subtracted.
+	// they default to 50ms and 2s
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// Retryable decides which errors are retried. it defaults to IsTransient
+	Retryable func(err error) bool
+}
+
+const (
+	defaultRetryAttempts     = 3
+	defaultTxRetryBackoff    = 50 * time.Millisecond
+	defaultMaxTxRetryBackoff = 2 * time.Second
+)
+
+// Run calls fn until it succeeds, returns an error that is not retryable or the attempts are used up.
+// the last error is returned
+func (p RetryPolicy) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	maxAttempts := p.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultRetryAttempts
+	}
+
+	backoff := p.Backoff
+	if backoff <= 0 {
+		backoff = defaultTxRetryBackoff
+	}
+
+	maxBackoff := p.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultMaxTxRetryBackoff
+	}
+
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsTransient
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= maxAttempts || !retryable(err) {
+			return err
+		}
+
+		wait := backoff - rand.N(backoff/2+1)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// WithTxRetry runs fn in a transaction like WithTx and starts over with a new transaction when it
+// fails with an error that policy retries. fn may run multiple times and must not have side effects
+// outside of the transaction
+func (mdb *Db) WithTxRetry(ctx context.Context, options *sql.TxOptions, policy RetryPolicy, fn func(tx DBOrTx) error) error {
+	return policy.Run(ctx, func(ctx context.Context) error {
+		return mdb.WithTx(ctx, options, fn)
+	})
+}
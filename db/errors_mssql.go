@@ -0,0 +1,36 @@
+package db
+
+import (
+	"errors"
+	mssqldriver "github.com/denisenkom/go-mssqldb"
+	"strings"
+)
+
+// ClassifyError maps the error numbers of sql server
+func (mssqlDialect) ClassifyError(err error) ErrorCategory {
+	var mssqlErr mssqldriver.Error
+	if !errors.As(err, &mssqlErr) {
+		return Unclassified
+	}
+
+	switch mssqlErr.Number {
+	case 2601, 2627:
+		return UniqueViolation
+	case 547:
+		// 547 is used for check constraints as well
+		if strings.Contains(mssqlErr.Message, "FOREIGN KEY") || strings.Contains(mssqlErr.Message, "REFERENCE") {
+			return ForeignKeyViolation
+		}
+		return Unclassified
+	case 515:
+		return NotNullViolation
+	case 1205:
+		return Deadlock
+	case 3960:
+		return SerializationFailure
+	case 1222:
+		return Busy
+	default:
+		return Unclassified
+	}
+}
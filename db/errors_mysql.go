@@ -0,0 +1,35 @@
+package db
+
+import (
+	"errors"
+	mysqldriver "github.com/go-sql-driver/mysql"
+)
+
+// ClassifyError maps the server error numbers of mysql and mariadb
+func (mysqlDialect) ClassifyError(err error) ErrorCategory {
+	if errors.Is(err, mysqldriver.ErrInvalidConn) {
+		return ConnectionLost
+	}
+
+	var mysqlErr *mysqldriver.MySQLError
+	if !errors.As(err, &mysqlErr) {
+		return Unclassified
+	}
+
+	switch mysqlErr.Number {
+	case 1062, 1586:
+		return UniqueViolation
+	case 1216, 1217, 1451, 1452:
+		return ForeignKeyViolation
+	case 1048, 1364:
+		return NotNullViolation
+	case 1213:
+		return Deadlock
+	case 1205:
+		return Busy
+	case 1053, 1927, 2006, 2013:
+		return ConnectionLost
+	default:
+		return Unclassified
+	}
+}
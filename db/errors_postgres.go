@@ -0,0 +1,39 @@
+package db
+
+import (
+	"errors"
+	"github.com/jackc/pgx/v5/pgconn"
+	"strings"
+)
+
+// ClassifyError maps the SQLSTATE codes of postgres
+func (postgresDialect) ClassifyError(err error) ErrorCategory {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return Unclassified
+	}
+
+	switch pgErr.Code {
+	case "23505":
+		return UniqueViolation
+	case "23503":
+		return ForeignKeyViolation
+	case "23502":
+		return NotNullViolation
+	case "40P01":
+		return Deadlock
+	case "40001":
+		return SerializationFailure
+	case "55P03":
+		return Locked
+	case "57P01", "57P02", "57P03":
+		return ConnectionLost
+	}
+
+	// class 08 are connection exceptions
+	if strings.HasPrefix(pgErr.Code, "08") {
+		return ConnectionLost
+	}
+
+	return Unclassified
+}
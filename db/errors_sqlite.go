@@ -0,0 +1,32 @@
+package db
+
+import (
+	"errors"
+	"github.com/mattn/go-sqlite3"
+)
+
+// ClassifyError maps the extended result codes of sqlite
+func (sqliteDialect) ClassifyError(err error) ErrorCategory {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return Unclassified
+	}
+
+	switch sqliteErr.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+		return UniqueViolation
+	case sqlite3.ErrConstraintForeignKey:
+		return ForeignKeyViolation
+	case sqlite3.ErrConstraintNotNull:
+		return NotNullViolation
+	}
+
+	switch sqliteErr.Code {
+	case sqlite3.ErrBusy:
+		return Busy
+	case sqlite3.ErrLocked:
+		return Locked
+	default:
+		return Unclassified
+	}
+}
@@ -0,0 +1,156 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	mssqldriver "github.com/denisenkom/go-mssqldb"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/mattn/go-sqlite3"
+	"testing"
+	"time"
+)
+
+func TestClassifySqlite(t *testing.T) {
+	d := getTestDb(t, DbConnectOptions{MaxOpenConns: 1})
+	queries := []string{
+		"PRAGMA foreign_keys = ON",
+		"CREATE TABLE parents (id INTEGER PRIMARY KEY)",
+		"CREATE TABLE children (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parents (id), name TEXT NOT NULL UNIQUE)",
+		"INSERT INTO parents (id) VALUES (1)",
+		"INSERT INTO children (id, parent_id, name) VALUES (1, 1, 'a')",
+	}
+	for _, query := range queries {
+		if _, err := d.Exec(query); err != nil {
+			t.Fatalf("%s failed: %v", query, err)
+		}
+	}
+
+	tests := []struct {
+		query    string
+		expected ErrorCategory
+	}{
+		{"INSERT INTO children (id, parent_id, name) VALUES (2, 1, 'a')", UniqueViolation},
+		{"INSERT INTO parents (id) VALUES (1)", UniqueViolation},
+		{"INSERT INTO children (id, parent_id, name) VALUES (3, 2, 'c')", ForeignKeyViolation},
+		{"INSERT INTO children (id, parent_id, name) VALUES (4, 1, NULL)", NotNullViolation},
+		{"SELECT * FROM missing", Unclassified},
+	}
+
+	for _, tt := range tests {
+		_, err := d.Exec(tt.query)
+		if err == nil {
+			t.Fatalf("%s: expected an error", tt.query)
+		}
+
+		if category := Classify(fmt.Errorf("wrapped: %w", err)); category != tt.expected {
+			t.Errorf("%s: expected %v, got %v (%v)", tt.query, tt.expected, category, err)
+		}
+	}
+}
+
+func TestClassifyDriverErrors(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected ErrorCategory
+	}{
+		{nil, Unclassified},
+		{errors.New("other"), Unclassified},
+		{&mysqldriver.MySQLError{Number: 1062}, UniqueViolation},
+		{&mysqldriver.MySQLError{Number: 1452}, ForeignKeyViolation},
+		{&mysqldriver.MySQLError{Number: 1048}, NotNullViolation},
+		{&mysqldriver.MySQLError{Number: 1213}, Deadlock},
+		{&mysqldriver.MySQLError{Number: 1205}, Busy},
+		{mysqldriver.ErrInvalidConn, ConnectionLost},
+		{&pgconn.PgError{Code: "23505"}, UniqueViolation},
+		{&pgconn.PgError{Code: "23503"}, ForeignKeyViolation},
+		{&pgconn.PgError{Code: "23502"}, NotNullViolation},
+		{&pgconn.PgError{Code: "40P01"}, Deadlock},
+		{&pgconn.PgError{Code: "40001"}, SerializationFailure},
+		{&pgconn.PgError{Code: "08006"}, ConnectionLost},
+		{&pgconn.PgError{Code: "42P01"}, Unclassified},
+		{mssqldriver.Error{Number: 2627}, UniqueViolation},
+		{mssqldriver.Error{Number: 547, Message: "The INSERT statement conflicted with the FOREIGN KEY constraint"}, ForeignKeyViolation},
+		{mssqldriver.Error{Number: 547, Message: "The INSERT statement conflicted with the CHECK constraint"}, Unclassified},
+		{mssqldriver.Error{Number: 515}, NotNullViolation},
+		{mssqldriver.Error{Number: 1205}, Deadlock},
+		{mssqldriver.Error{Number: 3960}, SerializationFailure},
+		{sqlite3.Error{Code: sqlite3.ErrBusy}, Busy},
+		{sqlite3.Error{Code: sqlite3.ErrLocked}, Locked},
+		{driver.ErrBadConn, ConnectionLost},
+		{context.Canceled, Unclassified},
+	}
+
+	for _, tt := range tests {
+		if category := Classify(tt.err); category != tt.expected {
+			t.Errorf("%v: expected %v, got %v", tt.err, tt.expected, category)
+		}
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	busy := sqlite3.Error{Code: sqlite3.ErrBusy}
+
+	attempts := 0
+	err := policy.Run(context.Background(), func(ctx context.Context) error {
+		attempts++
+		if attempts < 3 {
+			return busy
+		}
+		return nil
+	})
+	if err != nil || attempts != 3 {
+		t.Errorf("Expected success after 3 attempts, got %d: %v", attempts, err)
+	}
+
+	attempts = 0
+	err = policy.Run(context.Background(), func(ctx context.Context) error {
+		attempts++
+		return busy
+	})
+	if !errors.Is(err, busy) || attempts != 3 {
+		t.Errorf("Expected the last error after 3 attempts, got %d: %v", attempts, err)
+	}
+
+	attempts = 0
+	policy.Run(context.Background(), func(ctx context.Context) error {
+		attempts++
+		return &pgconn.PgError{Code: "23505"}
+	})
+	if attempts != 1 {
+		t.Errorf("Expected permanent errors not to be retried, got %d attempts", attempts)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = RetryPolicy{Backoff: time.Hour}.Run(ctx, func(ctx context.Context) error { return busy })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the canceled context to stop retrying, got %v", err)
+	}
+}
+
+func TestWithTxRetry(t *testing.T) {
+	d := setupTxTestTable(t)
+
+	attempts := 0
+	err := d.WithTxRetry(context.Background(), nil, RetryPolicy{Backoff: time.Millisecond}, func(tx DBOrTx) error {
+		attempts++
+		if _, err := tx.Exec("INSERT INTO items (name) VALUES ('a')"); err != nil {
+			return err
+		}
+		if attempts == 1 {
+			return fmt.Errorf("insert failed: %w", sqlite3.Error{Code: sqlite3.ErrBusy})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WithTxRetry failed: %v", err)
+	}
+
+	if count := countItems(t, d); count != 1 {
+		t.Errorf("Expected the first attempt to be rolled back, got %d rows", count)
+	}
+}
@@ -0,0 +1,723 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/MathiasMantai/gotools/file/zip"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFormat is the file format of ExportTable and ImportTable
+type ExportFormat string
+
+const (
+	// comma separated values with a header row. NULL is written as \N, bytes as \x followed by
+	// hex digits and timestamps in RFC 3339. values starting with a backslash get another one
+	FormatCSV ExportFormat = "csv"
+	// one JSON object per line. bytes are written as {"$bytes": "<base64>"} and timestamps as {"$time": "<RFC 3339>"}
+	FormatNDJSON ExportFormat = "ndjson"
+	// INSERT statements with the literal syntax of the dialect
+	FormatSQL ExportFormat = "sql"
+)
+
+// ExportOptions configures ExportTable
+type ExportOptions struct {
+	Format ExportFormat
+
+	// Query replaces SELECT * FROM table, for example to export a subset of the columns or rows.
+	// its ? placeholders are bound to Args
+	Query string
+	Args  []any
+
+	// BatchSize is the number of rows per INSERT statement of FormatSQL. it defaults to 100,
+	// mssql allows at most 1000
+	BatchSize int
+}
+
+// ImportOptions configures ImportTable
+type ImportOptions struct {
+	Format ExportFormat
+
+	// BatchSize is the maximum number of rows per INSERT statement. it defaults to 1000 and is
+	// lowered to stay within the placeholder limit
+	BatchSize int
+
+	// IdentityColumn is an auto increment column the imported rows contain values for.
+	// mssql enables IDENTITY_INSERT for it and postgres moves its sequence past the imported values
+	IdentityColumn string
+
+	// Progress is called with the number of rows imported so far after every batch
+	Progress func(imported int64)
+}
+
+// literalWriter is implemented by dialects whose literals differ from standardLiteral.
+// value is nil, int64, uint64, float64, bool, string, []byte or time.Time
+type literalWriter interface {
+	Literal(value any) string
+}
+
+// ExportTable streams all rows of table, or of options.Query, to w and returns the number of rows.
+// the export can be read by ImportTable of any database type except for FormatSQL which uses
+// the literals of the exporting database
+func (mdb *Db) ExportTable(ctx context.Context, w io.Writer, table string, options ExportOptions) (int64, error) {
+	dialect, err := mdb.Dialect()
+	if err != nil {
+		return 0, err
+	}
+
+	query := options.Query
+	if query == "" {
+		query = "SELECT * FROM " + dialect.QuoteIdentifier(table)
+	}
+
+	rows, err := mdb.QueryContext(ctx, Rebind(dialect.Placeholder(), query), options.Args...)
+	if err != nil {
+		return 0, fmt.Errorf("exporting %s failed: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, err
+	}
+
+	writer, err := newRowWriter(w, dialect, table, columns, options)
+	if err != nil {
+		return 0, err
+	}
+
+	values := make([]any, len(columns))
+	pointers := make([]any, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+
+	var exported int64
+	for rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return exported, err
+		}
+
+		row := make([]any, len(values))
+		for i, value := range values {
+			row[i], err = exportValue(value, columnTypes[i].DatabaseTypeName())
+			if err != nil {
+				return exported, fmt.Errorf("exporting column %s of %s failed: %w", columns[i], table, err)
+			}
+		}
+
+		if err := writer.writeRow(row); err != nil {
+			return exported, err
+		}
+		exported++
+	}
+
+	if err := rows.Err(); err != nil {
+		return exported, err
+	}
+
+	return exported, writer.flush()
+}
+
+// ExportTablesToZip exports every table into its own file named after the table, like users.csv,
+// and packages them into the zip archive fileName. the rows are streamed into the archive, so
+// memory use does not grow with the size of the tables. options.Query is not supported
+func (mdb *Db) ExportTablesToZip(ctx context.Context, fileName string, tables []string, options ExportOptions) (err error) {
+	if options.Query != "" {
+		return errors.New("a query cannot be exported into a zip of tables")
+	}
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		// no half written archives are left behind
+		if err != nil {
+			os.Remove(fileName)
+		}
+	}()
+
+	archive := zip.NewWriter(file)
+	for _, table := range tables {
+		w, err := archive.Create(table + "." + string(options.Format))
+		if err != nil {
+			return err
+		}
+
+		if _, err := mdb.ExportTable(ctx, w, table, options); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+// ImportTable reads rows written by ExportTable from r and inserts them into table with batched
+// INSERT statements inside of one transaction. the columns are taken from the CSV header or the
+// keys of the JSON objects. SQL scripts are executed statement by statement and table is only
+// used for options.IdentityColumn. the number of imported rows is returned
+func (mdb *Db) ImportTable(ctx context.Context, r io.Reader, table string, options ImportOptions) (int64, error) {
+	dialect, err := mdb.Dialect()
+	if err != nil {
+		return 0, err
+	}
+
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = 1000
+	}
+
+	var imported int64
+	err = mdb.WithTx(ctx, nil, func(tx DBOrTx) error {
+		var after []string
+		if inserter, ok := dialect.(identityInserter); ok && options.IdentityColumn != "" {
+			var before []string
+			before, after = inserter.IdentityInsertQueries(table, options.IdentityColumn)
+			for _, query := range before {
+				if _, err := tx.ExecContext(ctx, query); err != nil {
+					return fmt.Errorf("preparing table %s failed: %w", table, err)
+				}
+			}
+		}
+
+		var importErr error
+		switch options.Format {
+		case FormatCSV, FormatNDJSON:
+			var columns []string
+			var next RowIterator
+			if options.Format == FormatCSV {
+				columns, next, importErr = csvRows(r)
+			} else {
+				columns, next, importErr = ndjsonRows(r)
+			}
+			if importErr != nil {
+				return fmt.Errorf("importing %s failed: %w", table, importErr)
+			}
+			if columns == nil {
+				break
+			}
+
+			imported, importErr = insertBatches(ctx, tx, dialect, table, columns, next, batchSize, options.Progress)
+		case FormatSQL:
+			imported, importErr = execScript(ctx, tx, r, options.Progress)
+		default:
+			return fmt.Errorf("unsupported import format %q", options.Format)
+		}
+		if importErr != nil {
+			return fmt.Errorf("importing %s failed: %w", table, importErr)
+		}
+
+		for _, query := range after {
+			if _, err := tx.ExecContext(ctx, query); err != nil {
+				return fmt.Errorf("finishing table %s failed: %w", table, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return imported, nil
+}
+
+// exportValue normalizes a scanned value. drivers like mysql return text columns as []byte
+// which are only kept as bytes for binary column types
+func exportValue(value any, databaseType string) (any, error) {
+	normalized, err := normalizeValue(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if b, ok := normalized.([]byte); ok && !isBinaryType(databaseType) {
+		return string(b), nil
+	}
+
+	return normalized, nil
+}
+
+// isBinaryType reports if a column type holds bytes. sqlite reports no type for expressions
+// but only returns []byte for blobs
+func isBinaryType(databaseType string) bool {
+	databaseType = strings.ToUpper(databaseType)
+	if databaseType == "" {
+		return true
+	}
+
+	for _, binary := range []string{"BLOB", "BINARY", "BYTEA", "IMAGE", "UNIQUEIDENTIFIER"} {
+		if strings.Contains(databaseType, binary) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeValue resolves pointers and driver.Valuer and converts value into nil, int64,
+// uint64, float64, bool, string, []byte or time.Time
+func normalizeValue(value any) (any, error) {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil, nil
+	}
+
+	if valuer, ok := value.(driver.Valuer); ok {
+		resolved, err := valuer.Value()
+		if err != nil {
+			return nil, err
+		}
+		value = resolved
+		rv = reflect.ValueOf(value)
+	}
+
+	switch value.(type) {
+	case nil, time.Time, []byte:
+		return value, nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer:
+		return normalizeValue(rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	default:
+		return nil, fmt.Errorf("unsupported value of type %T", value)
+	}
+}
+
+// sqlLiteral formats value with the literal syntax of dialect
+func sqlLiteral(dialect Dialect, value any) (string, error) {
+	normalized, err := normalizeValue(value)
+	if err != nil {
+		return "", err
+	}
+
+	if writer, ok := dialect.(literalWriter); ok {
+		return writer.Literal(normalized), nil
+	}
+
+	return standardLiteral(normalized), nil
+}
+
+// standardLiteral formats a normalized value as standard SQL literal
+func standardLiteral(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case []byte:
+		return "X'" + hex.EncodeToString(v) + "'"
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05.999999999-07:00") + "'"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// rowWriter encodes exported rows
+type rowWriter interface {
+	writeRow(values []any) error
+	flush() error
+}
+
+func newRowWriter(w io.Writer, dialect Dialect, table string, columns []string, options ExportOptions) (rowWriter, error) {
+	switch options.Format {
+	case FormatCSV:
+		writer := &csvRowWriter{csv: csv.NewWriter(w)}
+		return writer, writer.csv.Write(columns)
+	case FormatNDJSON:
+		return &ndjsonRowWriter{w: bufio.NewWriter(w), columns: columns}, nil
+	case FormatSQL:
+		batchSize := options.BatchSize
+		if batchSize <= 0 {
+			batchSize = 100
+		}
+
+		insert := fmt.Sprintf("INSERT INTO %s (%s) VALUES\n",
+			dialect.QuoteIdentifier(table),
+			strings.Join(quoteIdentifiers(dialect, columns), ", "),
+		)
+		return &sqlRowWriter{w: bufio.NewWriter(w), dialect: dialect, insert: insert, batchSize: batchSize}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", options.Format)
+	}
+}
+
+type csvRowWriter struct {
+	csv *csv.Writer
+}
+
+func (c *csvRowWriter) writeRow(values []any) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = csvField(value)
+	}
+
+	return c.csv.Write(record)
+}
+
+func (c *csvRowWriter) flush() error {
+	c.csv.Flush()
+	return c.csv.Error()
+}
+
+func csvField(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return `\N`
+	case []byte:
+		return `\x` + hex.EncodeToString(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case string:
+		if strings.HasPrefix(v, `\`) {
+			return `\` + v
+		}
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// csvValue reverses csvField. timestamps stay text which the database converts on insert
+func csvValue(field string) (any, error) {
+	switch {
+	case field == `\N`:
+		return nil, nil
+	case strings.HasPrefix(field, `\x`):
+		return hex.DecodeString(field[2:])
+	case strings.HasPrefix(field, `\\`):
+		return field[1:], nil
+	default:
+		return field, nil
+	}
+}
+
+func csvRows(r io.Reader) ([]string, RowIterator, error) {
+	reader := csv.NewReader(r)
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return header, func() ([]any, error) {
+		record, err := reader.Read()
+		if err != nil {
+			return nil, err
+		}
+
+		row := make([]any, len(record))
+		for i, field := range record {
+			row[i], err = csvValue(field)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", header[i], err)
+			}
+		}
+
+		return row, nil
+	}, nil
+}
+
+type ndjsonRowWriter struct {
+	w       *bufio.Writer
+	columns []string
+}
+
+func (n *ndjsonRowWriter) writeRow(values []any) error {
+	n.w.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			n.w.WriteByte(',')
+		}
+
+		key, err := json.Marshal(n.columns[i])
+		if err != nil {
+			return err
+		}
+
+		encoded, err := json.Marshal(ndjsonValue(value))
+		if err != nil {
+			return err
+		}
+
+		n.w.Write(key)
+		n.w.WriteByte(':')
+		n.w.Write(encoded)
+	}
+	n.w.WriteString("}\n")
+
+	return nil
+}
+
+func (n *ndjsonRowWriter) flush() error {
+	return n.w.Flush()
+}
+
+// ndjsonValue wraps values without a JSON type into objects naming their type
+func ndjsonValue(value any) any {
+	switch v := value.(type) {
+	case []byte:
+		return map[string]string{"$bytes": base64.StdEncoding.EncodeToString(v)}
+	case time.Time:
+		return map[string]string{"$time": v.Format(time.RFC3339Nano)}
+	default:
+		return value
+	}
+}
+
+func ndjsonRows(r io.Reader) ([]string, RowIterator, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	readObject := func() (map[string]any, error) {
+		var object map[string]any
+		if err := decoder.Decode(&object); err != nil {
+			return nil, err
+		}
+		return object, nil
+	}
+
+	first, err := readObject()
+	if err == io.EOF {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	columns := make([]string, 0, len(first))
+	for column := range first {
+		columns = append(columns, column)
+	}
+	slices.Sort(columns)
+
+	pending := first
+	return columns, func() ([]any, error) {
+		object := pending
+		pending = nil
+		if object == nil {
+			var err error
+			if object, err = readObject(); err != nil {
+				return nil, err
+			}
+		}
+
+		row := make([]any, len(columns))
+		for column, value := range object {
+			i := slices.Index(columns, column)
+			if i < 0 {
+				return nil, fmt.Errorf("column %s is missing in the first row", column)
+			}
+
+			converted, err := ndjsonImportValue(value)
+			if err != nil {
+				return nil, fmt.Errorf("column %s: %w", column, err)
+			}
+			row[i] = converted
+		}
+
+		return row, nil
+	}, nil
+}
+
+// ndjsonImportValue reverses ndjsonValue
+func ndjsonImportValue(value any) (any, error) {
+	if object, ok := value.(map[string]any); ok && len(object) == 1 {
+		if encoded, ok := object["$bytes"].(string); ok {
+			return base64.StdEncoding.DecodeString(encoded)
+		}
+		if encoded, ok := object["$time"].(string); ok {
+			return time.Parse(time.RFC3339Nano, encoded)
+		}
+	}
+
+	return fixtureValue(value)
+}
+
+type sqlRowWriter struct {
+	w         *bufio.Writer
+	dialect   Dialect
+	insert    string
+	batchSize int
+	batch     int
+}
+
+func (s *sqlRowWriter) writeRow(values []any) error {
+	if s.batch == 0 {
+		s.w.WriteString(s.insert)
+	} else {
+		s.w.WriteString(",\n")
+	}
+
+	s.w.WriteByte('(')
+	for i, value := range values {
+		if i > 0 {
+			s.w.WriteString(", ")
+		}
+
+		literal, err := sqlLiteral(s.dialect, value)
+		if err != nil {
+			return err
+		}
+		s.w.WriteString(literal)
+	}
+	s.w.WriteByte(')')
+
+	s.batch++
+	if s.batch == s.batchSize {
+		s.w.WriteString(";\n")
+		s.batch = 0
+	}
+
+	return nil
+}
+
+func (s *sqlRowWriter) flush() error {
+	if s.batch > 0 {
+		s.w.WriteString(";\n")
+		s.batch = 0
+	}
+
+	return s.w.Flush()
+}
+
+// execScript executes the statements of an SQL script and returns the number of affected rows
+func execScript(ctx context.Context, tx DBOrTx, r io.Reader, progress func(imported int64)) (int64, error) {
+	reader := bufio.NewReader(r)
+
+	var affected int64
+	for {
+		statement, err := nextStatement(reader)
+		if err == io.EOF {
+			return affected, nil
+		}
+		if err != nil {
+			return affected, err
+		}
+
+		result, err := tx.ExecContext(ctx, statement)
+		if err != nil {
+			return affected, fmt.Errorf("statement failed after %d rows: %w", affected, err)
+		}
+
+		if n, err := result.RowsAffected(); err == nil {
+			affected += n
+		}
+		if progress != nil {
+			progress(affected)
+		}
+	}
+}
+
+// nextStatement reads up to the next semicolon outside of quotes and comments.
+// io.EOF is returned when only whitespace is left
+func nextStatement(reader *bufio.Reader) (string, error) {
+	var sb strings.Builder
+	var closing rune
+
+	for {
+		r, _, err := reader.ReadRune()
+		if err == io.EOF {
+			statement := strings.TrimSpace(sb.String())
+			if statement == "" {
+				return "", io.EOF
+			}
+			return statement, nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		// inside of a quote or comment everything up to the closing character is copied
+		if closing != 0 {
+			sb.WriteRune(r)
+			if r == closing && (closing != '*' || peekRune(reader) == '/') {
+				if closing == '*' {
+					next, _, _ := reader.ReadRune()
+					sb.WriteRune(next)
+				}
+				closing = 0
+			}
+			continue
+		}
+
+		switch {
+		case r == ';':
+			statement := strings.TrimSpace(sb.String())
+			if statement != "" {
+				return statement, nil
+			}
+			sb.Reset()
+			continue
+		case r == '\'' || r == '"' || r == '`':
+			closing = r
+		case r == '[':
+			closing = ']'
+		case r == '-' && peekRune(reader) == '-':
+			closing = '\n'
+		case r == '/' && peekRune(reader) == '*':
+			next, _, _ := reader.ReadRune()
+			sb.WriteRune(r)
+			r = next
+			closing = '*'
+		}
+
+		sb.WriteRune(r)
+	}
+}
+
+// peekRune returns the next rune without consuming it or 0 at the end
+func peekRune(reader *bufio.Reader) rune {
+	r, _, err := reader.ReadRune()
+	if err != nil {
+		return 0
+	}
+	reader.UnreadRune()
+
+	return r
+}
@@ -0,0 +1,232 @@
+package db
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type exportedRecord struct {
+	ID      int64
+	Name    *string
+	Data    []byte
+	Created time.Time
+}
+
+func setupExportTest(t *testing.T) *Db {
+	t.Helper()
+
+	d := getTestDb(t, DbConnectOptions{})
+	if _, err := d.Exec("CREATE TABLE records (id INTEGER PRIMARY KEY, name TEXT, data BLOB, created DATETIME NOT NULL)"); err != nil {
+		t.Fatalf("Creating table failed: %v", err)
+	}
+
+	return d
+}
+
+func exportTestRecords(t *testing.T, d *Db) {
+	t.Helper()
+
+	created := time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC)
+	rows := [][]any{
+		{1, "plain", []byte{0, 1, 2}, created},
+		{2, `\N`, []byte{}, created.Add(time.Hour)},
+		{3, nil, nil, created.Add(2 * time.Hour)},
+		{4, "it's, \"quoted\"\nand ; split", []byte("x"), created.Add(3 * time.Hour)},
+	}
+	if _, err := d.BulkInsert(context.Background(), "records", []string{"id", "name", "data", "created"}, RowsFromSlice(rows)); err != nil {
+		t.Fatalf("Inserting rows failed: %v", err)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source := setupExportTest(t)
+	exportTestRecords(t, source)
+
+	expected, err := Select[exportedRecord](ctx, source, "SELECT * FROM records ORDER BY id")
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+
+	for _, format := range []ExportFormat{FormatCSV, FormatNDJSON, FormatSQL} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			exported, err := source.ExportTable(ctx, &buf, "records", ExportOptions{Format: format, BatchSize: 3})
+			if err != nil {
+				t.Fatalf("ExportTable failed: %v", err)
+			}
+			if exported != 4 {
+				t.Errorf("Expected 4 exported rows, got %d", exported)
+			}
+
+			target := setupExportTest(t)
+			imported, err := target.ImportTable(ctx, &buf, "records", ImportOptions{Format: format})
+			if err != nil {
+				t.Fatalf("ImportTable failed: %v", err)
+			}
+			if imported != 4 {
+				t.Errorf("Expected 4 imported rows, got %d", imported)
+			}
+
+			actual, err := Select[exportedRecord](ctx, target, "SELECT * FROM records ORDER BY id")
+			if err != nil {
+				t.Fatalf("Select failed: %v", err)
+			}
+			if !reflect.DeepEqual(actual, expected) {
+				t.Errorf("Expected %+v, got %+v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestExportQuery(t *testing.T) {
+	d := setupExportTest(t)
+	exportTestRecords(t, d)
+
+	var buf bytes.Buffer
+	_, err := d.ExportTable(context.Background(), &buf, "records", ExportOptions{
+		Format: FormatCSV,
+		Query:  "SELECT id, name FROM records WHERE id > ? ORDER BY id",
+		Args:   []any{2},
+	})
+	if err != nil {
+		t.Fatalf("ExportTable failed: %v", err)
+	}
+
+	expected := "id,name\n3,\\N\n4,\"it's, \"\"quoted\"\"\nand ; split\"\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestImportRollsBack(t *testing.T) {
+	d := setupExportTest(t)
+
+	input := "id,name,data,created\n1,a,\\N,2024-01-01\n1,b,\\N,2024-01-01\n"
+	if _, err := d.ImportTable(context.Background(), strings.NewReader(input), "records", ImportOptions{Format: FormatCSV, BatchSize: 1}); err == nil {
+		t.Fatal("Expected the duplicate primary key to fail")
+	}
+
+	var count int
+	d.QueryRow("SELECT COUNT(*) FROM records").Scan(&count)
+	if count != 0 {
+		t.Errorf("Expected the import to be rolled back, got %d rows", count)
+	}
+}
+
+func TestExportTablesToZip(t *testing.T) {
+	d := setupExportTest(t)
+	exportTestRecords(t, d)
+
+	fileName := filepath.Join(t.TempDir(), "export.zip")
+	if err := d.ExportTablesToZip(context.Background(), fileName, []string{"records"}, ExportOptions{Format: FormatNDJSON}); err != nil {
+		t.Fatalf("ExportTablesToZip failed: %v", err)
+	}
+
+	archive, err := zip.OpenReader(fileName)
+	if err != nil {
+		t.Fatalf("Opening zip failed: %v", err)
+	}
+	defer archive.Close()
+
+	if len(archive.File) != 1 || archive.File[0].Name != "records.ndjson" {
+		t.Fatalf("Expected records.ndjson in the zip, got %v", archive.File)
+	}
+
+	file, err := archive.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	imported, err := setupExportTest(t).ImportTable(context.Background(), file, "records", ImportOptions{Format: FormatNDJSON})
+	if err != nil || imported != 4 {
+		t.Errorf("Expected 4 rows to be imported from the zip, got %d: %v", imported, err)
+	}
+}
+
+func TestExportTablesToZipRemovesFailedArchive(t *testing.T) {
+	d := setupExportTest(t)
+
+	fileName := filepath.Join(t.TempDir(), "export.zip")
+	if err := d.ExportTablesToZip(context.Background(), fileName, []string{"records", "missing"}, ExportOptions{Format: FormatCSV}); err == nil {
+		t.Fatalf("Expected the export of a missing table to fail")
+	}
+
+	if _, err := os.Stat(fileName); !os.IsNotExist(err) {
+		t.Errorf("Expected the incomplete archive to be removed, got %v", err)
+	}
+}
+
+func TestSQLLiteral(t *testing.T) {
+	created := time.Date(2024, 5, 1, 14, 30, 0, 500000000, time.FixedZone("CEST", 2*60*60))
+	name := "o'brien \\"
+
+	tests := []struct {
+		dbType   string
+		value    any
+		expected string
+	}{
+		{"mysql", name, `'o''brien \\'`},
+		{"mysql", created, "'2024-05-01 12:30:00.5'"},
+		{"mysql", []byte{0xab}, "X'ab'"},
+		{"postgres", &name, `'o''brien \'`},
+		{"postgres", []byte{0xab}, `'\xab'::bytea`},
+		{"postgres", created, "'2024-05-01 14:30:00.5+02:00'"},
+		{"postgres", true, "TRUE"},
+		{"mssql", name, `N'o''brien \'`},
+		{"mssql", []byte{0xab}, "0xab"},
+		{"mssql", created, "'2024-05-01T12:30:00.5'"},
+		{"mssql", false, "0"},
+		{"sqlite", nil, "NULL"},
+		{"sqlite", int32(-3), "-3"},
+		{"sqlite", 0.25, "0.25"},
+		{"sqlite", []byte{0xab}, "X'ab'"},
+	}
+
+	for _, tt := range tests {
+		dialect, err := GetDialect(tt.dbType)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		literal, err := sqlLiteral(dialect, tt.value)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.dbType, err)
+		}
+		if literal != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.dbType, tt.expected, literal)
+		}
+	}
+}
+
+func TestNextStatement(t *testing.T) {
+	script := "INSERT INTO a VALUES ('x;y');\n-- comment; here\nINSERT INTO \"b;\" VALUES (1) /* ; */;;\n  INSERT INTO c VALUES ('it''s;')"
+
+	reader := bufio.NewReader(strings.NewReader(script))
+	var statements []string
+	for {
+		statement, err := nextStatement(reader)
+		if err != nil {
+			break
+		}
+		statements = append(statements, statement)
+	}
+
+	expected := []string{
+		"INSERT INTO a VALUES ('x;y')",
+		"-- comment; here\nINSERT INTO \"b;\" VALUES (1) /* ; */",
+		"INSERT INTO c VALUES ('it''s;')",
+	}
+	if !reflect.DeepEqual(statements, expected) {
+		t.Errorf("Expected %q, got %q", expected, statements)
+	}
+}
@@ -0,0 +1,309 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Fixtures maps table names to the rows inserted into them. a row maps column names to values
+type Fixtures map[string][]map[string]any
+
+// FixtureOptions configures LoadFixtures
+type FixtureOptions struct {
+	// Migrations provide the foreign keys that determine the insert order and the
+	// auto increment columns. tables without a migration are inserted in alphabetical order
+	Migrations []Migration
+
+	// Truncate deletes all rows of the fixture tables before inserting
+	Truncate bool
+}
+
+// identityInserter is implemented by dialects which need extra statements to insert
+// explicit values into an auto increment column
+type identityInserter interface {
+	IdentityInsertQueries(table string, column string) (before []string, after []string)
+}
+
+// ReadFixtures reads fixtures from .json, .yaml and .yml files. rows of a table
+// found in multiple files are appended in the order of the files
+func ReadFixtures(paths ...string) (Fixtures, error) {
+	fixtures := Fixtures{}
+	for _, filePath := range paths {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := fixtures.parse(filepath.Base(filePath), data); err != nil {
+			return nil, err
+		}
+	}
+
+	return fixtures, nil
+}
+
+// ReadFixturesFS reads all .json, .yaml and .yml files of dir in fsys, for example an embed.FS,
+// in alphabetical order
+func ReadFixturesFS(fsys fs.FS, dir string) (Fixtures, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := Fixtures{}
+	for _, entry := range entries {
+		if entry.IsDir() || fixtureFormat(entry.Name()) == "" {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		if err := fixtures.parse(entry.Name(), data); err != nil {
+			return nil, err
+		}
+	}
+
+	return fixtures, nil
+}
+
+func fixtureFormat(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	default:
+		return ""
+	}
+}
+
+// parse decodes a fixture file and appends its rows
+func (f Fixtures) parse(fileName string, data []byte) error {
+	var tables map[string][]map[string]any
+
+	switch fixtureFormat(fileName) {
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&tables); err != nil {
+			return fmt.Errorf("parsing fixture file %s failed: %w", fileName, err)
+		}
+	case "yaml":
+		if err := yaml.Unmarshal(data, &tables); err != nil {
+			return fmt.Errorf("parsing fixture file %s failed: %w", fileName, err)
+		}
+	default:
+		return fmt.Errorf("unsupported fixture file %s: expected .json, .yaml or .yml", fileName)
+	}
+
+	for table, rows := range tables {
+		for _, row := range rows {
+			for column, value := range row {
+				converted, err := fixtureValue(value)
+				if err != nil {
+					return fmt.Errorf("fixture file %s: column %s of table %s: %w", fileName, column, table, err)
+				}
+				row[column] = converted
+			}
+		}
+		f[table] = append(f[table], rows...)
+	}
+
+	return nil
+}
+
+// fixtureValue converts decoded values into values accepted by all drivers.
+// numbers become int64 where possible and nested objects or lists are stored as json
+func fixtureValue(value any) (any, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	case int:
+		return int64(v), nil
+	case map[string]any, []any:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(encoded), nil
+	default:
+		return v, nil
+	}
+}
+
+// LoadFixtures inserts fixtures inside of one transaction. referenced tables are filled before
+// the tables referencing them and, with options.Truncate, emptied after them
+func (mdb *Db) LoadFixtures(ctx context.Context, fixtures Fixtures, options FixtureOptions) error {
+	dialect, err := mdb.Dialect()
+	if err != nil {
+		return err
+	}
+
+	tables := make([]string, 0, len(fixtures))
+	for table := range fixtures {
+		tables = append(tables, table)
+	}
+
+	order, err := fixtureOrder(tables, options.Migrations)
+	if err != nil {
+		return err
+	}
+
+	autoIncrement := map[string]string{}
+	for _, migration := range options.Migrations {
+		for _, field := range migration.Fields {
+			if field.AutoIncrement {
+				autoIncrement[migration.TableName] = field.Name
+				break
+			}
+		}
+	}
+
+	return mdb.WithTx(ctx, nil, func(tx DBOrTx) error {
+		if options.Truncate {
+			for i := len(order) - 1; i >= 0; i-- {
+				if _, err := tx.ExecContext(ctx, "DELETE FROM "+dialect.QuoteIdentifier(order[i])); err != nil {
+					return fmt.Errorf("truncating table %s failed: %w", order[i], err)
+				}
+			}
+		}
+
+		for _, table := range order {
+			if err := insertFixtureRows(ctx, tx, dialect, table, fixtures[table], autoIncrement[table]); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func insertFixtureRows(ctx context.Context, tx DBOrTx, dialect Dialect, table string, rows []map[string]any, autoIncrementColumn string) error {
+	var after []string
+	if inserter, ok := dialect.(identityInserter); ok && autoIncrementColumn != "" && rowsContain(rows, autoIncrementColumn) {
+		var before []string
+		before, after = inserter.IdentityInsertQueries(table, autoIncrementColumn)
+		for _, query := range before {
+			if _, err := tx.ExecContext(ctx, query); err != nil {
+				return fmt.Errorf("preparing table %s failed: %w", table, err)
+			}
+		}
+	}
+
+	for i, row := range rows {
+		columns := make([]string, 0, len(row))
+		for column := range row {
+			columns = append(columns, column)
+		}
+		sort.Strings(columns)
+
+		quoted := make([]string, len(columns))
+		placeholders := make([]string, len(columns))
+		args := make([]any, len(columns))
+		for j, column := range columns {
+			quoted[j] = dialect.QuoteIdentifier(column)
+			placeholders[j] = dialect.Placeholder().Placeholder(j + 1)
+			args[j] = row[column]
+		}
+
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+			dialect.QuoteIdentifier(table),
+			strings.Join(quoted, ", "),
+			strings.Join(placeholders, ", "),
+		)
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("inserting row %d into %s failed: %w", i+1, table, err)
+		}
+	}
+
+	for _, query := range after {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("finishing table %s failed: %w", table, err)
+		}
+	}
+
+	return nil
+}
+
+func rowsContain(rows []map[string]any, column string) bool {
+	for _, row := range rows {
+		if _, ok := row[column]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// fixtureOrder sorts tables so that every table comes after the tables its foreign keys reference.
+// references to tables outside of tables and self references are ignored
+func fixtureOrder(tables []string, migrations []Migration) ([]string, error) {
+	included := map[string]bool{}
+	for _, table := range tables {
+		included[table] = true
+	}
+
+	dependencies := map[string]map[string]bool{}
+	for _, migration := range migrations {
+		if !included[migration.TableName] {
+			continue
+		}
+		for _, fKey := range migration.ForeignKeys {
+			if included[fKey.ReferenceTable] && fKey.ReferenceTable != migration.TableName {
+				if dependencies[migration.TableName] == nil {
+					dependencies[migration.TableName] = map[string]bool{}
+				}
+				dependencies[migration.TableName][fKey.ReferenceTable] = true
+			}
+		}
+	}
+
+	remaining := append([]string{}, tables...)
+	sort.Strings(remaining)
+
+	order := make([]string, 0, len(tables))
+	done := map[string]bool{}
+	for len(remaining) > 0 {
+		var next []string
+		progress := false
+		for _, table := range remaining {
+			ready := true
+			for dependency := range dependencies[table] {
+				if !done[dependency] {
+					ready = false
+					break
+				}
+			}
+
+			if ready {
+				order = append(order, table)
+				done[table] = true
+				progress = true
+			} else {
+				next = append(next, table)
+			}
+		}
+
+		if !progress {
+			return nil, fmt.Errorf("foreign keys between the fixture tables %v form a cycle", next)
+		}
+		remaining = next
+	}
+
+	return order, nil
+}
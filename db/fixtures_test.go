@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+var fixtureMigrations = []Migration{
+	{
+		TableName: "orders",
+		Fields: []MigrationField{
+			{Name: "id", DataType: "INTEGER", PrimaryKey: true, AutoIncrement: true},
+			{Name: "user_id", DataType: "INTEGER"},
+			{Name: "meta", DataType: "TEXT", Nullable: true},
+		},
+		ForeignKeys: []ForeignKey{
+			{Name: "fk_orders_user", Column: "user_id", ReferenceTable: "users", ReferenceColumn: "id"},
+		},
+	},
+	{
+		TableName: "users",
+		Fields: []MigrationField{
+			{Name: "id", DataType: "INTEGER", PrimaryKey: true, AutoIncrement: true},
+			{Name: "name", DataType: "TEXT"},
+		},
+	},
+}
+
+func setupFixtureTest(t *testing.T) *Db {
+	t.Helper()
+
+	d := getTestDb(t, DbConnectOptions{Params: map[string]string{"_foreign_keys": "1"}})
+
+	// referenced tables have to exist first
+	if err := CreateMigrations(d, []Migration{fixtureMigrations[1], fixtureMigrations[0]}); err != nil {
+		t.Fatalf("CreateMigrations failed: %v", err)
+	}
+
+	return d
+}
+
+func TestFixtureOrder(t *testing.T) {
+	order, err := fixtureOrder([]string{"orders", "users", "audit"}, fixtureMigrations)
+	if err != nil {
+		t.Fatalf("fixtureOrder failed: %v", err)
+	}
+
+	if strings.Join(order, ",") != "audit,users,orders" {
+		t.Errorf("Expected audit,users,orders, got %v", order)
+	}
+
+	cyclic := []Migration{
+		{TableName: "a", ForeignKeys: []ForeignKey{{ReferenceTable: "b"}}},
+		{TableName: "b", ForeignKeys: []ForeignKey{{ReferenceTable: "a"}}},
+	}
+	if _, err := fixtureOrder([]string{"a", "b"}, cyclic); err == nil {
+		t.Error("Expected an error for cyclic foreign keys")
+	}
+}
+
+func TestLoadFixturesFromFiles(t *testing.T) {
+	d := setupFixtureTest(t)
+
+	dir := t.TempDir()
+	jsonFile := filepath.Join(dir, "orders.json")
+	yamlFile := filepath.Join(dir, "users.yaml")
+	os.WriteFile(jsonFile, []byte(`{"orders": [{"id": 10, "user_id": 1, "meta": {"gift": true}}]}`), 0644)
+	os.WriteFile(yamlFile, []byte("users:\n  - id: 1\n    name: alice\n"), 0644)
+
+	fixtures, err := ReadFixtures(jsonFile, yamlFile)
+	if err != nil {
+		t.Fatalf("ReadFixtures failed: %v", err)
+	}
+
+	if err := d.LoadFixtures(context.Background(), fixtures, FixtureOptions{Migrations: fixtureMigrations}); err != nil {
+		t.Fatalf("LoadFixtures failed: %v", err)
+	}
+
+	var name, meta string
+	err = d.QueryRow("SELECT u.name, o.meta FROM orders o JOIN users u ON u.id = o.user_id WHERE o.id = 10").Scan(&name, &meta)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if name != "alice" || meta != `{"gift":true}` {
+		t.Errorf("Unexpected row: %s %s", name, meta)
+	}
+}
+
+func TestLoadFixturesTruncate(t *testing.T) {
+	d := setupFixtureTest(t)
+	ctx := context.Background()
+
+	fsys := fstest.MapFS{
+		"fixtures/users.yml":  {Data: []byte("users:\n  - id: 1\n    name: alice\n")},
+		"fixtures/orders.yml": {Data: []byte("orders:\n  - id: 1\n    user_id: 1\n")},
+		"fixtures/README.md":  {Data: []byte("ignored")},
+	}
+
+	fixtures, err := ReadFixturesFS(fsys, "fixtures")
+	if err != nil {
+		t.Fatalf("ReadFixturesFS failed: %v", err)
+	}
+
+	options := FixtureOptions{Migrations: fixtureMigrations, Truncate: true}
+	for i := 0; i < 2; i++ {
+		if err := d.LoadFixtures(ctx, fixtures, options); err != nil {
+			t.Fatalf("LoadFixtures run %d failed: %v", i+1, err)
+		}
+	}
+
+	var count int
+	if err := d.QueryRow("SELECT COUNT(*) FROM orders").Scan(&count); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if count != 1 {
+		t.Errorf("Expected truncated tables to contain 1 order, got %d", count)
+	}
+
+	// without truncating the duplicate primary keys fail and nothing is inserted
+	if err := d.LoadFixtures(ctx, Fixtures{"users": {{"id": 2, "name": "bob"}, {"id": 1, "name": "alice"}}}, FixtureOptions{}); err == nil {
+		t.Fatal("Expected an error for a duplicate primary key")
+	}
+
+	if err := d.QueryRow("SELECT COUNT(*) FROM users").Scan(&count); err != nil || count != 1 {
+		t.Errorf("Expected the failed load to be rolled back, got %d users (%v)", count, err)
+	}
+}
+
+func TestReadFixturesUnsupportedFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "users.csv")
+	os.WriteFile(file, []byte("id\n1\n"), 0644)
+
+	if _, err := ReadFixtures(file); err == nil {
+		t.Error("Expected an error for an unsupported file type")
+	}
+}
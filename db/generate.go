@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/MathiasMantai/gotools/db/codegen"
+)
+
+// goTypeMapper is implemented by dialects which know the Go types their column types are scanned into
+type goTypeMapper interface {
+	GoType(dataType string) string
+}
+
+// GenerateStructs returns a gofmt'd Go file with one struct per migration. column types are
+// mapped with the dialect of dbType, unknown types become any
+func GenerateStructs(dbType string, migrations []Migration, options codegen.Options) ([]byte, error) {
+	dialect, err := GetDialect(dbType)
+	if err != nil {
+		return nil, err
+	}
+
+	mapper, ok := dialect.(goTypeMapper)
+	if !ok {
+		return nil, fmt.Errorf("code generation is not supported for database type %s", dbType)
+	}
+
+	tables := make([]codegen.Table, len(migrations))
+	for i, migration := range migrations {
+		tables[i].Name = migration.TableName
+		for _, field := range migration.Fields {
+			tables[i].Columns = append(tables[i].Columns, codegen.Column{
+				Name:     field.Name,
+				GoType:   mapper.GoType(field.DataType),
+				Nullable: field.Nullable,
+			})
+		}
+	}
+
+	return codegen.Generate(tables, options)
+}
+
+// GenerateStructs introspects the connected database and returns a gofmt'd Go file with one struct per table
+func (mdb *Db) GenerateStructs(ctx context.Context, options codegen.Options) ([]byte, error) {
+	migrations, err := mdb.Introspect(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return GenerateStructs(mdb.DbType, migrations, options)
+}
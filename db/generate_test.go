@@ -0,0 +1,54 @@
+package db
+
+import (
+	"context"
+	"github.com/MathiasMantai/gotools/db/codegen"
+	"strings"
+	"testing"
+)
+
+func TestGenerateStructsFromMigrations(t *testing.T) {
+	migrations := []Migration{
+		{
+			TableName: "events",
+			Fields: []MigrationField{
+				{Name: "id", DataType: "bigserial", PrimaryKey: true, AutoIncrement: true},
+				{Name: "payload", DataType: "jsonb", Nullable: true},
+				{Name: "happened_at", DataType: "timestamp with time zone"},
+			},
+		},
+	}
+
+	code, err := GenerateStructs("postgres", migrations, codegen.Options{Package: "events"})
+	if err != nil {
+		t.Fatalf("GenerateStructs failed: %v", err)
+	}
+
+	for _, expected := range []string{"package events", "\"encoding/json\"", "\"time\"", "ID         int64", "Payload    json.RawMessage", "HappenedAt time.Time"} {
+		if !strings.Contains(string(code), expected) {
+			t.Errorf("Expected %q in\n%s", expected, code)
+		}
+	}
+
+	if _, err := GenerateStructs("oracle", migrations, codegen.Options{}); err == nil {
+		t.Error("Expected an error for an unregistered database type")
+	}
+}
+
+func TestGenerateStructsFromDatabase(t *testing.T) {
+	d := getTestDb(t, DbConnectOptions{})
+	if _, err := d.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, born DATE)"); err != nil {
+		t.Fatalf("Creating table failed: %v", err)
+	}
+
+	code, err := d.GenerateStructs(context.Background(), codegen.Options{DbTags: true, NullTypes: true})
+	if err != nil {
+		t.Fatalf("GenerateStructs failed: %v", err)
+	}
+
+	for _, expected := range []string{"type Users struct", "ID   int64", "Name string", "Born sql.NullTime `db:\"born\"`"} {
+		if !strings.Contains(string(code), expected) {
+			t.Errorf("Expected %q in\n%s", expected, code)
+		}
+	}
+}
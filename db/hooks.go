@@ -0,0 +1,229 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/MathiasMantai/gotools/logger"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// operations reported in QueryEvent.Operation
+const (
+	OpQuery    = "query"
+	OpQueryRow = "query_row"
+	OpExec     = "exec"
+	OpBegin    = "begin"
+)
+
+// QueryEvent describes a statement executed through Db or a Tx created by WithTx
+type QueryEvent struct {
+	Operation string
+	Query     string
+	Args      []any
+	Start     time.Time
+
+	// the following fields are only set for the After callback
+	Duration time.Duration
+
+	// -1 if unknown, which is always the case for queries
+	RowsAffected int64
+	Err          error
+}
+
+// Hook observes statements. Before may return a derived context that is used for the statement
+// and passed to After. After callbacks run in reverse order, so hooks wrap each other like middleware
+type Hook struct {
+	Before func(ctx context.Context, event *QueryEvent) context.Context
+	After  func(ctx context.Context, event *QueryEvent)
+}
+
+// AddHook appends hooks to the hook chain. hooks have to be added before the Db is used concurrently
+func (mdb *Db) AddHook(hooks ...Hook) {
+	mdb.Hooks = append(mdb.Hooks, hooks...)
+}
+
+// runHooks calls fn surrounded by the before and after callbacks of hooks and returns the error of fn
+func runHooks(ctx context.Context, hooks []Hook, operation string, query string, args []any, fn func(ctx context.Context, event *QueryEvent) error) error {
+	event := &QueryEvent{
+		Operation:    operation,
+		Query:        query,
+		Args:         args,
+		Start:        time.Now(),
+		RowsAffected: -1,
+	}
+
+	if len(hooks) == 0 {
+		return fn(ctx, event)
+	}
+
+	for _, hook := range hooks {
+		if hook.Before != nil {
+			if hookCtx := hook.Before(ctx, event); hookCtx != nil {
+				ctx = hookCtx
+			}
+		}
+	}
+
+	event.Err = fn(ctx, event)
+	event.Duration = time.Since(event.Start)
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if hooks[i].After != nil {
+			hooks[i].After(ctx, event)
+		}
+	}
+
+	return event.Err
+}
+
+func (e *QueryEvent) setRowsAffected(result sql.Result, err error) {
+	if err != nil || result == nil {
+		return
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err == nil {
+		e.RowsAffected = rowsAffected
+	}
+}
+
+func hookedExec(ctx context.Context, hooks []Hook, exec func(context.Context, string, ...any) (sql.Result, error), query string, args []any) (sql.Result, error) {
+	var result sql.Result
+	err := runHooks(ctx, hooks, OpExec, query, args, func(ctx context.Context, event *QueryEvent) error {
+		var err error
+		result, err = exec(ctx, query, args...)
+		event.setRowsAffected(result, err)
+		return err
+	})
+
+	return result, err
+}
+
+func hookedQuery(ctx context.Context, hooks []Hook, query func(context.Context, string, ...any) (*sql.Rows, error), queryString string, args []any) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := runHooks(ctx, hooks, OpQuery, queryString, args, func(ctx context.Context, event *QueryEvent) error {
+		var err error
+		rows, err = query(ctx, queryString, args...)
+		return err
+	})
+
+	return rows, err
+}
+
+func hookedQueryRow(ctx context.Context, hooks []Hook, queryRow func(context.Context, string, ...any) *sql.Row, query string, args []any) *sql.Row {
+	var row *sql.Row
+	runHooks(ctx, hooks, OpQueryRow, query, args, func(ctx context.Context, event *QueryEvent) error {
+		row = queryRow(ctx, query, args...)
+		return row.Err()
+	})
+
+	return row
+}
+
+/*****************
+	BUILTIN HOOKS
+******************/
+
+// SlowQueryHook logs every statement taking at least threshold as a warning
+func SlowQueryHook(l *logger.Logger, threshold time.Duration) Hook {
+	return Hook{
+		After: func(ctx context.Context, event *QueryEvent) {
+			if event.Duration < threshold {
+				return
+			}
+
+			l.LogWarningf("slow %s (%v): %s", event.Operation, event.Duration, Fingerprint(event.Query))
+		},
+	}
+}
+
+// QueryCounter counts executed statements per fingerprint
+type QueryCounter struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+// Hook returns the hook which has to be added to a Db for counting
+func (c *QueryCounter) Hook() Hook {
+	return Hook{
+		After: func(ctx context.Context, event *QueryEvent) {
+			if event.Operation == OpBegin {
+				return
+			}
+
+			fingerprint := Fingerprint(event.Query)
+
+			c.mu.Lock()
+			defer c.mu.Unlock()
+
+			if c.counts == nil {
+				c.counts = map[string]int64{}
+			}
+			c.counts[fingerprint]++
+		},
+	}
+}
+
+// Counts returns a copy of the counts per fingerprint
+func (c *QueryCounter) Counts() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[string]int64, len(c.counts))
+	for fingerprint, count := range c.counts {
+		counts[fingerprint] = count
+	}
+
+	return counts
+}
+
+// String lists the counted fingerprints, most frequent first
+func (c *QueryCounter) String() string {
+	counts := c.Counts()
+
+	fingerprints := make([]string, 0, len(counts))
+	for fingerprint := range counts {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	sort.Slice(fingerprints, func(i, j int) bool {
+		if counts[fingerprints[i]] != counts[fingerprints[j]] {
+			return counts[fingerprints[i]] > counts[fingerprints[j]]
+		}
+		return fingerprints[i] < fingerprints[j]
+	})
+
+	var sb strings.Builder
+	for _, fingerprint := range fingerprints {
+		sb.WriteString(fmt.Sprintf("%6d  %s\n", counts[fingerprint], fingerprint))
+	}
+
+	return sb.String()
+}
+
+// Reset removes all counts
+func (c *QueryCounter) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts = nil
+}
+
+var (
+	fingerprintLiterals    = regexp.MustCompile(`'(?:[^']|'')*'|\b\d+(?:\.\d+)?\b|\$\d+|@p\d+`)
+	fingerprintInLists     = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)+\s*\)`)
+	fingerprintWhitespaces = regexp.MustCompile(`\s+`)
+)
+
+// Fingerprint normalizes a query so that statements only differing in literal values,
+// placeholder style or formatting are considered equal
+func Fingerprint(query string) string {
+	fingerprint := fingerprintWhitespaces.ReplaceAllString(strings.TrimSpace(query), " ")
+	fingerprint = fingerprintLiterals.ReplaceAllString(fingerprint, "?")
+	fingerprint = fingerprintInLists.ReplaceAllString(fingerprint, "(?+)")
+
+	return strings.ToLower(fingerprint)
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"testing"
+)
+
+type hookTestKey struct{}
+
+func TestHooksReceiveEvents(t *testing.T) {
+	d := setupTxTestTable(t)
+
+	var order []string
+	var events []QueryEvent
+
+	d.AddHook(
+		Hook{
+			Before: func(ctx context.Context, event *QueryEvent) context.Context {
+				order = append(order, "before outer")
+				return context.WithValue(ctx, hookTestKey{}, "marker")
+			},
+			After: func(ctx context.Context, event *QueryEvent) {
+				order = append(order, "after outer")
+				events = append(events, *event)
+			},
+		},
+		Hook{
+			Before: func(ctx context.Context, event *QueryEvent) context.Context {
+				order = append(order, "before inner")
+				if ctx.Value(hookTestKey{}) != "marker" {
+					t.Error("Expected context of the previous hook")
+				}
+				return nil
+			},
+			After: func(ctx context.Context, event *QueryEvent) {
+				order = append(order, "after inner")
+			},
+		},
+	)
+
+	_, err := d.Exec("INSERT INTO items (name) VALUES (?), (?)", "a", "b")
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+
+	expectedOrder := []string{"before outer", "before inner", "after inner", "after outer"}
+	if len(order) != len(expectedOrder) {
+		t.Fatalf("Expected %v, got %v", expectedOrder, order)
+	}
+	for i := range order {
+		if order[i] != expectedOrder[i] {
+			t.Fatalf("Expected %v, got %v", expectedOrder, order)
+		}
+	}
+
+	event := events[0]
+	if event.Operation != OpExec || event.RowsAffected != 2 || len(event.Args) != 2 || event.Err != nil {
+		t.Errorf("Unexpected exec event: %+v", event)
+	}
+
+	d.QueryRow("SELECT name FROM missing_table")
+	event = events[len(events)-1]
+	if event.Operation != OpQueryRow || event.Err == nil || event.RowsAffected != -1 {
+		t.Errorf("Unexpected query row event: %+v", event)
+	}
+}
+
+func TestHooksObserveTransactions(t *testing.T) {
+	d := setupTxTestTable(t)
+
+	var operations []string
+	d.AddHook(Hook{
+		After: func(ctx context.Context, event *QueryEvent) {
+			operations = append(operations, event.Operation)
+		},
+	})
+
+	err := d.WithTx(context.Background(), nil, func(tx DBOrTx) error {
+		_, err := tx.Exec("INSERT INTO items (name) VALUES (?)", "a")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTx failed: %v", err)
+	}
+
+	if len(operations) != 2 || operations[0] != OpBegin || operations[1] != OpExec {
+		t.Errorf("Expected [begin exec], got %v", operations)
+	}
+}
+
+func TestQueryCounter(t *testing.T) {
+	d := setupTxTestTable(t)
+
+	var counter QueryCounter
+	d.AddHook(counter.Hook())
+
+	d.Exec("INSERT INTO items (name) VALUES ('a')")
+	d.Exec("INSERT INTO items (name)   VALUES ('b')")
+	d.Query("SELECT name FROM items WHERE name IN (?, ?, ?)", "a", "b", "c")
+
+	counts := counter.Counts()
+	if counts["insert into items (name) values (?)"] != 2 {
+		t.Errorf("Expected 2 inserts, got %v", counts)
+	}
+
+	if counts["select name from items where name in (?+)"] != 1 {
+		t.Errorf("Expected 1 select, got %v", counts)
+	}
+
+	counter.Reset()
+	if len(counter.Counts()) != 0 {
+		t.Error("Expected counts to be empty after reset")
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	tests := map[string]string{
+		"SELECT * FROM t WHERE id = 5":              "select * from t where id = ?",
+		"SELECT * FROM t WHERE id = $1":             "select * from t where id = ?",
+		"SELECT *\n  FROM t2 WHERE id = @p12":       "select * from t2 where id = ?",
+		"UPDATE t SET name = 'it''s' WHERE x = 1.5": "update t set name = ? where x = ?",
+		"DELETE FROM t WHERE id IN (1, 2, 3)":       "delete from t where id in (?+)",
+	}
+
+	for query, expected := range tests {
+		if result := Fingerprint(query); result != expected {
+			t.Errorf("Fingerprint(%q): expected %q, got %q", query, expected, result)
+		}
+	}
+}
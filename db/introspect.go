@@ -0,0 +1,129 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// introspector is implemented by dialects which can read the structure of an existing database
+type introspector interface {
+	Introspect(ctx context.Context, conn DBOrTx) ([]Migration, error)
+}
+
+// Introspect reads the tables of the connected database, or of its default schema, as migrations
+// including fields, primary keys, auto increment columns, foreign keys and indexes.
+// tables are sorted by name and fields keep their order in the table.
+// DataType contains the type as reported by the database, for example varchar(255)
+func (mdb *Db) Introspect(ctx context.Context) ([]Migration, error) {
+	dialect, err := mdb.Dialect()
+	if err != nil {
+		return nil, err
+	}
+
+	inspector, ok := dialect.(introspector)
+	if !ok {
+		return nil, fmt.Errorf("introspection is not supported for database type %s", mdb.DbType)
+	}
+
+	migrations, err := inspector.Introspect(Primary(ctx), mdb)
+	if err != nil {
+		return nil, fmt.Errorf("introspecting database failed: %w", err)
+	}
+
+	return migrations, nil
+}
+
+// schemaBuilder collects the results of the catalog queries of a dialect
+type schemaBuilder struct {
+	tables []*Migration
+	byName map[string]*Migration
+}
+
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{byName: map[string]*Migration{}}
+}
+
+// table returns the table called name and adds it if it is new
+func (s *schemaBuilder) table(name string) *Migration {
+	if table, ok := s.byName[name]; ok {
+		return table
+	}
+
+	table := &Migration{
+		TableName:   name,
+		Fields:      []MigrationField{},
+		ForeignKeys: []ForeignKey{},
+		Indexes:     []Index{},
+	}
+	s.tables = append(s.tables, table)
+	s.byName[name] = table
+
+	return table
+}
+
+// field returns a field of a known table or nil
+func (s *schemaBuilder) field(table string, name string) *MigrationField {
+	t, ok := s.byName[table]
+	if !ok {
+		return nil
+	}
+
+	for i := range t.Fields {
+		if t.Fields[i].Name == name {
+			return &t.Fields[i]
+		}
+	}
+
+	return nil
+}
+
+// addIndexColumn appends column to the index of a known table. columns have to be added in index order
+func (s *schemaBuilder) addIndexColumn(table string, index string, unique bool, column string) {
+	t, ok := s.byName[table]
+	if !ok {
+		return
+	}
+
+	for i := range t.Indexes {
+		if t.Indexes[i].Name == index {
+			t.Indexes[i].Columns = append(t.Indexes[i].Columns, column)
+			return
+		}
+	}
+
+	t.Indexes = append(t.Indexes, Index{Name: index, Columns: []string{column}, Unique: unique})
+}
+
+// addForeignKey appends a foreign key to a known table
+func (s *schemaBuilder) addForeignKey(table string, fKey ForeignKey) {
+	if t, ok := s.byName[table]; ok {
+		t.ForeignKeys = append(t.ForeignKeys, fKey)
+	}
+}
+
+func (s *schemaBuilder) migrations() []Migration {
+	migrations := make([]Migration, len(s.tables))
+	for i, table := range s.tables {
+		migrations[i] = *table
+	}
+
+	return migrations
+}
+
+// queryEach runs query and calls fn for every row
+func queryEach(ctx context.Context, conn DBOrTx, query string, args []any, fn func(rows *sql.Rows) error) error {
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := fn(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Introspect reads the sys catalog views for the default schema of the user
+func (mssqlDialect) Introspect(ctx context.Context, conn DBOrTx) ([]Migration, error) {
+	schema := newSchemaBuilder()
+
+	tablesQuery := `
+		SELECT t.name
+		FROM sys.tables t
+		WHERE t.schema_id = SCHEMA_ID() AND t.is_ms_shipped = 0
+		ORDER BY t.name
+	`
+	err := queryEach(ctx, conn, tablesQuery, nil, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		schema.table(name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	columnsQuery := `
+		SELECT t.name, c.name, ty.name, c.max_length, c.precision, c.scale, c.is_nullable, c.is_identity
+		FROM sys.columns c
+		JOIN sys.tables t ON t.object_id = c.object_id
+		JOIN sys.types ty ON ty.user_type_id = c.user_type_id
+		WHERE t.schema_id = SCHEMA_ID() AND t.is_ms_shipped = 0
+		ORDER BY t.name, c.column_id
+	`
+	err = queryEach(ctx, conn, columnsQuery, nil, func(rows *sql.Rows) error {
+		var table, name, dataType string
+		var maxLength int
+		var precision, scale int
+		var nullable, identity bool
+		if err := rows.Scan(&table, &name, &dataType, &maxLength, &precision, &scale, &nullable, &identity); err != nil {
+			return err
+		}
+
+		t := schema.table(table)
+		t.Fields = append(t.Fields, MigrationField{
+			Name:          name,
+			DataType:      mssqlColumnType(dataType, maxLength, precision, scale),
+			Nullable:      nullable,
+			AutoIncrement: identity,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	indexesQuery := `
+		SELECT t.name, i.name, i.is_primary_key, i.is_unique, c.name
+		FROM sys.indexes i
+		JOIN sys.tables t ON t.object_id = i.object_id
+		JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+		JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+		WHERE t.schema_id = SCHEMA_ID() AND t.is_ms_shipped = 0 AND i.type > 0 AND ic.is_included_column = 0
+		ORDER BY t.name, i.name, ic.key_ordinal
+	`
+	err = queryEach(ctx, conn, indexesQuery, nil, func(rows *sql.Rows) error {
+		var table, index, column string
+		var primaryKey, unique bool
+		if err := rows.Scan(&table, &index, &primaryKey, &unique, &column); err != nil {
+			return err
+		}
+
+		if !primaryKey {
+			schema.addIndexColumn(table, index, unique, column)
+		} else if field := schema.field(table, column); field != nil {
+			field.PrimaryKey = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	foreignKeysQuery := `
+		SELECT t.name, fk.name, c.name, rt.name, rc.name
+		FROM sys.foreign_keys fk
+		JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id
+		JOIN sys.tables t ON t.object_id = fkc.parent_object_id
+		JOIN sys.columns c ON c.object_id = fkc.parent_object_id AND c.column_id = fkc.parent_column_id
+		JOIN sys.tables rt ON rt.object_id = fkc.referenced_object_id
+		JOIN sys.columns rc ON rc.object_id = fkc.referenced_object_id AND rc.column_id = fkc.referenced_column_id
+		WHERE t.schema_id = SCHEMA_ID()
+		ORDER BY t.name, fk.name, fkc.constraint_column_id
+	`
+	err = queryEach(ctx, conn, foreignKeysQuery, nil, func(rows *sql.Rows) error {
+		var table string
+		var fKey ForeignKey
+		if err := rows.Scan(&table, &fKey.Name, &fKey.Column, &fKey.ReferenceTable, &fKey.ReferenceColumn); err != nil {
+			return err
+		}
+		schema.addForeignKey(table, fKey)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.migrations(), nil
+}
+
+// mssqlColumnType adds length, precision and scale to a type name like CREATE TABLE expects them
+func mssqlColumnType(dataType string, maxLength int, precision int, scale int) string {
+	switch strings.ToLower(dataType) {
+	case "varchar", "char", "varbinary", "binary":
+		if maxLength == -1 {
+			return dataType + "(max)"
+		}
+		return fmt.Sprintf("%s(%d)", dataType, maxLength)
+	case "nvarchar", "nchar":
+		// max_length counts bytes of two byte characters
+		if maxLength == -1 {
+			return dataType + "(max)"
+		}
+		return fmt.Sprintf("%s(%d)", dataType, maxLength/2)
+	case "decimal", "numeric":
+		return fmt.Sprintf("%s(%d,%d)", dataType, precision, scale)
+	default:
+		return dataType
+	}
+}
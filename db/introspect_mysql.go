@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// Introspect reads information_schema for the current database
+func (mysqlDialect) Introspect(ctx context.Context, conn DBOrTx) ([]Migration, error) {
+	schema := newSchemaBuilder()
+
+	tablesQuery := `
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'
+		ORDER BY table_name
+	`
+	err := queryEach(ctx, conn, tablesQuery, nil, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		schema.table(name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	columnsQuery := `
+		SELECT table_name, column_name, column_type, is_nullable, column_key, extra
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE()
+		ORDER BY table_name, ordinal_position
+	`
+	err = queryEach(ctx, conn, columnsQuery, nil, func(rows *sql.Rows) error {
+		var table, name, dataType, nullable, key, extra string
+		if err := rows.Scan(&table, &name, &dataType, &nullable, &key, &extra); err != nil {
+			return err
+		}
+
+		// views are listed as well
+		if _, ok := schema.byName[table]; !ok {
+			return nil
+		}
+
+		t := schema.table(table)
+		t.Fields = append(t.Fields, MigrationField{
+			Name:          name,
+			DataType:      dataType,
+			Nullable:      nullable == "YES",
+			PrimaryKey:    key == "PRI",
+			AutoIncrement: strings.Contains(strings.ToLower(extra), "auto_increment"),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	foreignKeysQuery := `
+		SELECT table_name, constraint_name, column_name, referenced_table_name, referenced_column_name
+		FROM information_schema.key_column_usage
+		WHERE table_schema = DATABASE() AND referenced_table_name IS NOT NULL
+		ORDER BY table_name, constraint_name, ordinal_position
+	`
+	err = queryEach(ctx, conn, foreignKeysQuery, nil, func(rows *sql.Rows) error {
+		var table string
+		var fKey ForeignKey
+		if err := rows.Scan(&table, &fKey.Name, &fKey.Column, &fKey.ReferenceTable, &fKey.ReferenceColumn); err != nil {
+			return err
+		}
+		schema.addForeignKey(table, fKey)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	indexesQuery := `
+		SELECT table_name, index_name, non_unique, column_name
+		FROM information_schema.statistics
+		WHERE table_schema = DATABASE() AND index_name <> 'PRIMARY'
+		ORDER BY table_name, index_name, seq_in_index
+	`
+	err = queryEach(ctx, conn, indexesQuery, nil, func(rows *sql.Rows) error {
+		var table, index string
+		var nonUnique int
+		var column sql.NullString
+		if err := rows.Scan(&table, &index, &nonUnique, &column); err != nil {
+			return err
+		}
+
+		// functional indexes have no column
+		schema.addIndexColumn(table, index, nonUnique == 0, column.String)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.migrations(), nil
+}
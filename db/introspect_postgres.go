@@ -0,0 +1,146 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Introspect reads information_schema for the current schema. foreign keys are read from
+// pg_constraint and indexes, which are not part of information_schema, from pg_index
+func (postgresDialect) Introspect(ctx context.Context, conn DBOrTx) ([]Migration, error) {
+	schema := newSchemaBuilder()
+
+	tablesQuery := `
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = current_schema() AND table_type = 'BASE TABLE'
+		ORDER BY table_name
+	`
+	err := queryEach(ctx, conn, tablesQuery, nil, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		schema.table(name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	columnsQuery := `
+		SELECT table_name, column_name, data_type, character_maximum_length, numeric_precision, numeric_scale,
+			is_nullable, COALESCE(column_default, ''), is_identity
+		FROM information_schema.columns
+		WHERE table_schema = current_schema()
+		ORDER BY table_name, ordinal_position
+	`
+	err = queryEach(ctx, conn, columnsQuery, nil, func(rows *sql.Rows) error {
+		var table, name, dataType, nullable, defaultValue, identity string
+		var length, precision, scale sql.NullInt64
+		if err := rows.Scan(&table, &name, &dataType, &length, &precision, &scale, &nullable, &defaultValue, &identity); err != nil {
+			return err
+		}
+
+		if _, ok := schema.byName[table]; !ok {
+			return nil
+		}
+
+		switch {
+		case length.Valid:
+			dataType = fmt.Sprintf("%s(%d)", dataType, length.Int64)
+		case dataType == "numeric" && precision.Valid:
+			dataType = fmt.Sprintf("numeric(%d,%d)", precision.Int64, scale.Int64)
+		}
+
+		t := schema.table(table)
+		t.Fields = append(t.Fields, MigrationField{
+			Name:          name,
+			DataType:      dataType,
+			Nullable:      nullable == "YES",
+			AutoIncrement: identity == "YES" || strings.HasPrefix(defaultValue, "nextval("),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	primaryKeysQuery := `
+		SELECT kcu.table_name, kcu.column_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON kcu.constraint_schema = tc.constraint_schema AND kcu.constraint_name = tc.constraint_name
+			AND kcu.table_name = tc.table_name
+		WHERE tc.table_schema = current_schema() AND tc.constraint_type = 'PRIMARY KEY'
+	`
+	err = queryEach(ctx, conn, primaryKeysQuery, nil, func(rows *sql.Rows) error {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return err
+		}
+
+		if field := schema.field(table, column); field != nil {
+			field.PrimaryKey = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// constraint names are only unique per table, so foreign keys are read from pg_constraint
+	// where conkey and confkey pair the columns by position
+	foreignKeysQuery := `
+		SELECT t.relname, c.conname, a.attname, rt.relname, ra.attname
+		FROM pg_constraint c
+		JOIN pg_class t ON t.oid = c.conrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN pg_class rt ON rt.oid = c.confrelid
+		JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, refattnum, position) ON true
+		JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+		JOIN pg_attribute ra ON ra.attrelid = c.confrelid AND ra.attnum = k.refattnum
+		WHERE n.nspname = current_schema() AND c.contype = 'f'
+		ORDER BY t.relname, c.conname, k.position
+	`
+	err = queryEach(ctx, conn, foreignKeysQuery, nil, func(rows *sql.Rows) error {
+		var table string
+		var fKey ForeignKey
+		if err := rows.Scan(&table, &fKey.Name, &fKey.Column, &fKey.ReferenceTable, &fKey.ReferenceColumn); err != nil {
+			return err
+		}
+		schema.addForeignKey(table, fKey)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	indexesQuery := `
+		SELECT t.relname, i.relname, ix.indisunique, a.attname
+		FROM pg_index ix
+		JOIN pg_class t ON t.oid = ix.indrelid
+		JOIN pg_class i ON i.oid = ix.indexrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		JOIN LATERAL unnest(ix.indkey) WITH ORDINALITY AS k(attnum, position) ON true
+		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+		WHERE n.nspname = current_schema() AND NOT ix.indisprimary
+		ORDER BY t.relname, i.relname, k.position
+	`
+	err = queryEach(ctx, conn, indexesQuery, nil, func(rows *sql.Rows) error {
+		var table, index, column string
+		var unique bool
+		if err := rows.Scan(&table, &index, &unique, &column); err != nil {
+			return err
+		}
+		schema.addIndexColumn(table, index, unique, column)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return schema.migrations(), nil
+}
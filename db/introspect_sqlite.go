@@ -0,0 +1,144 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Introspect reads sqlite_master and the table_info, foreign_key_list and index_list pragmas
+func (d sqliteDialect) Introspect(ctx context.Context, conn DBOrTx) ([]Migration, error) {
+	schema := newSchemaBuilder()
+
+	err := queryEach(ctx, conn, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name", nil, func(rows *sql.Rows) error {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		schema.table(name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, table := range schema.tables {
+		if err := d.introspectColumns(ctx, conn, table); err != nil {
+			return nil, err
+		}
+
+		if err := d.introspectForeignKeys(ctx, conn, schema, table.TableName); err != nil {
+			return nil, err
+		}
+
+		if err := d.introspectIndexes(ctx, conn, schema, table.TableName); err != nil {
+			return nil, err
+		}
+	}
+
+	return schema.migrations(), nil
+}
+
+func (d sqliteDialect) introspectColumns(ctx context.Context, conn DBOrTx, table *Migration) error {
+	primaryKeys := 0
+	err := queryEach(ctx, conn, fmt.Sprintf("PRAGMA table_info(%s)", d.QuoteIdentifier(table.TableName)), nil, func(rows *sql.Rows) error {
+		var cid, notNull, pk int
+		var name, dataType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &dataType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+
+		if pk > 0 {
+			primaryKeys++
+		}
+
+		table.Fields = append(table.Fields, MigrationField{
+			Name:       name,
+			DataType:   dataType,
+			Nullable:   notNull == 0 && pk == 0,
+			PrimaryKey: pk > 0,
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// a single INTEGER PRIMARY KEY column is an alias of the rowid and assigned automatically
+	if primaryKeys == 1 {
+		for i := range table.Fields {
+			if table.Fields[i].PrimaryKey && strings.EqualFold(table.Fields[i].DataType, "INTEGER") {
+				table.Fields[i].AutoIncrement = true
+			}
+		}
+	}
+
+	return nil
+}
+
+func (d sqliteDialect) introspectForeignKeys(ctx context.Context, conn DBOrTx, schema *schemaBuilder, table string) error {
+	return queryEach(ctx, conn, fmt.Sprintf("PRAGMA foreign_key_list(%s)", d.QuoteIdentifier(table)), nil, func(rows *sql.Rows) error {
+		var id, seq int
+		var referenceTable, column, onUpdate, onDelete, match string
+		var referenceColumn sql.NullString
+		if err := rows.Scan(&id, &seq, &referenceTable, &column, &referenceColumn, &onUpdate, &onDelete, &match); err != nil {
+			return err
+		}
+
+		// sqlite does not keep constraint names
+		schema.addForeignKey(table, ForeignKey{
+			Name:            fmt.Sprintf("fk_%s_%s", table, column),
+			Column:          column,
+			ReferenceTable:  referenceTable,
+			ReferenceColumn: referenceColumn.String,
+		})
+		return nil
+	})
+}
+
+func (d sqliteDialect) introspectIndexes(ctx context.Context, conn DBOrTx, schema *schemaBuilder, table string) error {
+	type indexInfo struct {
+		name   string
+		unique bool
+	}
+
+	var indexes []indexInfo
+	err := queryEach(ctx, conn, fmt.Sprintf("PRAGMA index_list(%s)", d.QuoteIdentifier(table)), nil, func(rows *sql.Rows) error {
+		var seq, unique, partial int
+		var name, origin string
+		if err := rows.Scan(&seq, &name, &unique, &origin, &partial); err != nil {
+			return err
+		}
+
+		if origin != "pk" {
+			indexes = append(indexes, indexInfo{name: name, unique: unique == 1})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// index_list returns the newest index first
+	for i := len(indexes) - 1; i >= 0; i-- {
+		index := indexes[i]
+		err := queryEach(ctx, conn, fmt.Sprintf("PRAGMA index_info(%s)", d.QuoteIdentifier(index.name)), nil, func(rows *sql.Rows) error {
+			var seqNo int
+			var cid int
+			var column sql.NullString
+			if err := rows.Scan(&seqNo, &cid, &column); err != nil {
+				return err
+			}
+
+			schema.addIndexColumn(table, index.name, index.unique, column.String)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/MathiasMantai/gotools/db/mysql"
+	"github.com/MathiasMantai/gotools/db/postgres"
+	"reflect"
+	"testing"
+)
+
+func TestIntrospectSqlite(t *testing.T) {
+	d := getTestDb(t, DbConnectOptions{})
+
+	statements := []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email VARCHAR(255) NOT NULL, nickname TEXT)",
+		"CREATE UNIQUE INDEX idx_users_email ON users (email)",
+		"CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users (id), total REAL)",
+		"CREATE INDEX idx_orders_user_total ON orders (user_id, total)",
+	}
+	for _, statement := range statements {
+		if _, err := d.Exec(statement); err != nil {
+			t.Fatalf("Creating schema failed: %v", err)
+		}
+	}
+
+	migrations, err := d.Introspect(context.Background())
+	if err != nil {
+		t.Fatalf("Introspect failed: %v", err)
+	}
+
+	if len(migrations) != 2 || migrations[0].TableName != "orders" || migrations[1].TableName != "users" {
+		t.Fatalf("Expected the tables orders and users, got %+v", migrations)
+	}
+
+	users := migrations[1]
+	expectedFields := []MigrationField{
+		{Name: "id", DataType: "INTEGER", PrimaryKey: true, AutoIncrement: true},
+		{Name: "email", DataType: "VARCHAR(255)"},
+		{Name: "nickname", DataType: "TEXT", Nullable: true},
+	}
+	if !reflect.DeepEqual(users.Fields, expectedFields) {
+		t.Errorf("Expected fields %+v, got %+v", expectedFields, users.Fields)
+	}
+
+	if len(users.Indexes) != 1 || !reflect.DeepEqual(users.Indexes[0], Index{Name: "idx_users_email", Columns: []string{"email"}, Unique: true}) {
+		t.Errorf("Unexpected indexes of users: %+v", users.Indexes)
+	}
+
+	orders := migrations[0]
+	expectedFk := ForeignKey{Name: "fk_orders_user_id", Column: "user_id", ReferenceTable: "users", ReferenceColumn: "id"}
+	if len(orders.ForeignKeys) != 1 || orders.ForeignKeys[0] != expectedFk {
+		t.Errorf("Expected foreign key %+v, got %+v", expectedFk, orders.ForeignKeys)
+	}
+
+	if len(orders.Indexes) != 1 || !reflect.DeepEqual(orders.Indexes[0].Columns, []string{"user_id", "total"}) {
+		t.Errorf("Unexpected indexes of orders: %+v", orders.Indexes)
+	}
+}
+
+func TestIntrospectMysql(t *testing.T) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDb.Close()
+
+	mock.ExpectQuery("FROM information_schema.tables").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("orders"))
+	mock.ExpectQuery("FROM information_schema.columns").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "column_type", "is_nullable", "column_key", "extra"}).
+			AddRow("orders", "id", "int unsigned", "NO", "PRI", "auto_increment").
+			AddRow("orders", "user_id", "int", "NO", "MUL", "").
+			AddRow("order_view", "id", "int", "NO", "", ""))
+	mock.ExpectQuery("FROM information_schema.key_column_usage").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "constraint_name", "column_name", "referenced_table_name", "referenced_column_name"}).
+			AddRow("orders", "fk_orders_user", "user_id", "users", "id"))
+	mock.ExpectQuery("FROM information_schema.statistics").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "index_name", "non_unique", "column_name"}).
+			AddRow("orders", "fk_orders_user", 1, "user_id"))
+
+	d := &Db{DbObj: &mysql.MySqlDb{DbObj: mockDb}, DbType: "mysql"}
+	migrations, err := d.Introspect(context.Background())
+	if err != nil {
+		t.Fatalf("Introspect failed: %v", err)
+	}
+
+	expected := []Migration{{
+		TableName: "orders",
+		Fields: []MigrationField{
+			{Name: "id", DataType: "int unsigned", PrimaryKey: true, AutoIncrement: true},
+			{Name: "user_id", DataType: "int"},
+		},
+		ForeignKeys: []ForeignKey{{Name: "fk_orders_user", Column: "user_id", ReferenceTable: "users", ReferenceColumn: "id"}},
+		Indexes:     []Index{{Name: "fk_orders_user", Columns: []string{"user_id"}}},
+	}}
+	if !reflect.DeepEqual(migrations, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, migrations)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestIntrospectPostgresForeignKeysWithSameName(t *testing.T) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDb.Close()
+
+	mock.ExpectQuery("FROM information_schema.tables").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("orders").AddRow("reviews"))
+	mock.ExpectQuery("FROM information_schema.columns").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name", "data_type", "character_maximum_length", "numeric_precision", "numeric_scale", "is_nullable", "column_default", "is_identity"}).
+			AddRow("orders", "user_id", "integer", nil, 32, 0, "NO", "", "NO").
+			AddRow("reviews", "author_id", "integer", nil, 32, 0, "NO", "", "NO"))
+	mock.ExpectQuery("FROM information_schema.table_constraints").
+		WillReturnRows(sqlmock.NewRows([]string{"table_name", "column_name"}))
+	// both tables have a foreign key named fk_user
+	mock.ExpectQuery("FROM pg_constraint").
+		WillReturnRows(sqlmock.NewRows([]string{"relname", "conname", "attname", "relname", "attname"}).
+			AddRow("orders", "fk_user", "user_id", "users", "id").
+			AddRow("reviews", "fk_user", "author_id", "users", "id"))
+	mock.ExpectQuery("FROM pg_index").
+		WillReturnRows(sqlmock.NewRows([]string{"relname", "relname", "indisunique", "attname"}))
+
+	d := &Db{DbObj: &postgres.PgSqlDb{DbObj: mockDb}, DbType: "postgres"}
+	migrations, err := d.Introspect(context.Background())
+	if err != nil {
+		t.Fatalf("Introspect failed: %v", err)
+	}
+
+	if len(migrations) != 2 {
+		t.Fatalf("Expected 2 tables, got %+v", migrations)
+	}
+	expected := map[string]ForeignKey{
+		"orders":  {Name: "fk_user", Column: "user_id", ReferenceTable: "users", ReferenceColumn: "id"},
+		"reviews": {Name: "fk_user", Column: "author_id", ReferenceTable: "users", ReferenceColumn: "id"},
+	}
+	for _, migration := range migrations {
+		if len(migration.ForeignKeys) != 1 || migration.ForeignKeys[0] != expected[migration.TableName] {
+			t.Errorf("Expected foreign key %+v for %s, got %+v", expected[migration.TableName], migration.TableName, migration.ForeignKeys)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestMssqlColumnType(t *testing.T) {
+	tests := []struct {
+		dataType  string
+		maxLength int
+		precision int
+		scale     int
+		expected  string
+	}{
+		{"nvarchar", 510, 0, 0, "nvarchar(255)"},
+		{"nvarchar", -1, 0, 0, "nvarchar(max)"},
+		{"varchar", 50, 0, 0, "varchar(50)"},
+		{"decimal", 9, 10, 2, "decimal(10,2)"},
+		{"int", 4, 10, 0, "int"},
+	}
+
+	for _, test := range tests {
+		if result := mssqlColumnType(test.dataType, test.maxLength, test.precision, test.scale); result != test.expected {
+			t.Errorf("Expected %s, got %s", test.expected, result)
+		}
+	}
+}
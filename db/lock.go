@@ -0,0 +1,128 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrLockTimeout is returned by Lock if another connection held the lock for the whole timeout
+var ErrLockTimeout = errors.New("timeout acquiring lock")
+
+// interval in which dialects without blocking locks try again
+const lockPollInterval = 50 * time.Millisecond
+
+// locker is implemented by dialects supporting named locks. the lock has to be held by conn
+// and the returned function releases it. if a lock belongs to the session and acquiring or
+// releasing it fails, the dialect discards conn with discardConn
+type locker interface {
+	AcquireLock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) (func(ctx context.Context) error, error)
+}
+
+// Lock is a named lock acquired by Db.Lock
+type Lock struct {
+	Name string
+
+	conn    *sql.Conn
+	release func(ctx context.Context) error
+
+	mu       sync.Mutex
+	released bool
+}
+
+// Lock acquires the named lock which excludes every other connection to the database, for example
+// of other instances of a service running the same job. it waits up to timeout, zero or less only tries once,
+// and returns ErrLockTimeout if the lock stays taken. the lock is held by a dedicated connection
+// taken from the pool and released by Unlock or when that connection dies:
+//   - postgres uses session level advisory locks with a 64 bit hash of name as key
+//   - mysql uses GET_LOCK
+//   - mssql uses sp_getapplock with the session as owner
+//   - sqlite uses a lock table whose rows expire unless renewed by the holding process
+func (mdb *Db) Lock(ctx context.Context, name string, timeout time.Duration) (*Lock, error) {
+	dialect, err := mdb.Dialect()
+	if err != nil {
+		return nil, err
+	}
+
+	l, ok := dialect.(locker)
+	if !ok {
+		return nil, fmt.Errorf("named locks are not supported by %s", mdb.DbType)
+	}
+
+	conn, err := mdb.DbObj.DB().Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("getting a connection for lock %s failed: %w", name, err)
+	}
+
+	release, err := l.AcquireLock(ctx, conn, name, timeout)
+	if err != nil {
+		conn.Close()
+		if errors.Is(err, ErrLockTimeout) {
+			return nil, fmt.Errorf("lock %s: %w", name, err)
+		}
+		return nil, fmt.Errorf("acquiring lock %s failed: %w", name, err)
+	}
+
+	return &Lock{Name: name, conn: conn, release: release}, nil
+}
+
+// Unlock releases the lock and returns its connection to the pool. if releasing a session level lock fails
+// the connection is closed instead, which frees the lock. calling it again does nothing
+func (l *Lock) Unlock(ctx context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.released {
+		return nil
+	}
+	l.released = true
+
+	err := l.release(ctx)
+	if closeErr := l.conn.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("releasing lock %s failed: %w", l.Name, err)
+	}
+
+	return nil
+}
+
+// discardConn closes the connection of conn instead of returning it to the pool. session level
+// locks die with their connection, so a connection which may still hold a lock after an error
+// does not leak it into the pool
+func discardConn(conn *sql.Conn) {
+	conn.Raw(func(any) error { return driver.ErrBadConn })
+}
+
+// pollLock calls try until it acquires the lock, fails or timeout passed
+func pollLock(ctx context.Context, timeout time.Duration, try func() (bool, error)) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		acquired, err := try()
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+
+		wait := min(lockPollInterval, time.Until(deadline))
+		if wait <= 0 {
+			return ErrLockTimeout
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// AcquireLock uses sp_getapplock with the session as owner so no transaction has to stay open
+func (mssqlDialect) AcquireLock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) (func(ctx context.Context) error, error) {
+	query := `
+		DECLARE @result int;
+		EXEC @result = sp_getapplock @Resource = @p1, @LockMode = 'Exclusive', @LockOwner = 'Session', @LockTimeout = @p2;
+		SELECT @result;
+	`
+
+	var result int
+	if err := conn.QueryRowContext(ctx, query, name, max(timeout.Milliseconds(), 0)).Scan(&result); err != nil {
+		discardConn(conn)
+		return nil, err
+	}
+
+	// 0 and 1 mean granted, -1 timeout, -2 canceled, -3 deadlock victim and -999 invalid parameters
+	switch {
+	case result >= 0:
+	case result == -1:
+		return nil, ErrLockTimeout
+	default:
+		return nil, fmt.Errorf("sp_getapplock returned %d", result)
+	}
+
+	return func(ctx context.Context) error {
+		if _, err := conn.ExecContext(ctx, "EXEC sp_releaseapplock @Resource = @p1, @LockOwner = 'Session'", name); err != nil {
+			discardConn(conn)
+			return err
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,48 @@
+package db
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"math"
+	"time"
+)
+
+// mysql rejects longer lock names
+const maxMysqlLockName = 64
+
+// AcquireLock uses GET_LOCK which waits in whole seconds. names longer than 64 characters are hashed
+func (mysqlDialect) AcquireLock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) (func(ctx context.Context) error, error) {
+	if len(name) > maxMysqlLockName {
+		sum := sha1.Sum([]byte(name))
+		name = hex.EncodeToString(sum[:])
+	}
+
+	seconds := int64(math.Ceil(max(timeout, 0).Seconds()))
+
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, seconds).Scan(&acquired); err != nil {
+		discardConn(conn)
+		return nil, err
+	}
+	if !acquired.Valid {
+		return nil, errors.New("GET_LOCK failed")
+	}
+	if acquired.Int64 != 1 {
+		return nil, ErrLockTimeout
+	}
+
+	return func(ctx context.Context) error {
+		var released sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT RELEASE_LOCK(?)", name).Scan(&released); err != nil {
+			discardConn(conn)
+			return err
+		}
+		if released.Int64 != 1 {
+			return errors.New("lock was not held")
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// AcquireLock takes a session level advisory lock. waiting uses lock_timeout
+// so the server gives up instead of the query being canceled
+func (postgresDialect) AcquireLock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) (func(ctx context.Context) error, error) {
+	key := advisoryLockKey(name)
+
+	release := func(ctx context.Context) error {
+		var released bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", key).Scan(&released); err != nil {
+			discardConn(conn)
+			return err
+		}
+		if !released {
+			return errors.New("lock was not held")
+		}
+		return nil
+	}
+
+	if timeout <= 0 {
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil {
+			discardConn(conn)
+			return nil, err
+		}
+		if !acquired {
+			return nil, ErrLockTimeout
+		}
+		return release, nil
+	}
+
+	milliseconds := max(timeout.Milliseconds(), 1)
+	if _, err := conn.ExecContext(ctx, "SELECT set_config('lock_timeout', $1, false)", fmt.Sprintf("%dms", milliseconds)); err != nil {
+		discardConn(conn)
+		return nil, err
+	}
+
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key)
+	_, resetErr := conn.ExecContext(ctx, "RESET lock_timeout")
+
+	// the connection may hold the lock or keep the lock_timeout, so it is only reused after a clean timeout
+	if err != nil && resetErr == nil && (postgresDialect{}).ClassifyError(err) == Locked {
+		return nil, ErrLockTimeout
+	}
+	if err == nil {
+		err = resetErr
+	}
+	if err != nil {
+		discardConn(conn)
+		return nil, err
+	}
+
+	return release, nil
+}
+
+// advisoryLockKey hashes name into the bigint key space of advisory locks
+func advisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"time"
+)
+
+// sqliteLockLease is how long a lock row stays valid without being renewed.
+// the holder renews it after a third of the lease
+const sqliteLockLease = 15 * time.Second
+
+const sqliteLockTable = `
+	CREATE TABLE IF NOT EXISTS "_gotools_locks" (
+		"name" TEXT PRIMARY KEY,
+		"owner" TEXT NOT NULL,
+		"expires_at" INTEGER NOT NULL
+	)
+`
+
+// AcquireLock inserts a row into the lock table or takes over an expired one. sqlite has no
+// sessions visible to other processes, so the row is a lease which the process holding the lock
+// renews in the background. if the process dies the lock is free after the lease expired
+func (sqliteDialect) AcquireLock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) (func(ctx context.Context) error, error) {
+	if _, err := conn.ExecContext(ctx, sqliteLockTable); err != nil {
+		return nil, err
+	}
+
+	token := make([]byte, 16)
+	rand.Read(token)
+	owner := hex.EncodeToString(token)
+
+	err := pollLock(ctx, timeout, func() (bool, error) {
+		now := time.Now()
+		result, err := conn.ExecContext(ctx, `
+			INSERT INTO "_gotools_locks" ("name", "owner", "expires_at") VALUES (?, ?, ?)
+			ON CONFLICT ("name") DO UPDATE SET "owner" = excluded."owner", "expires_at" = excluded."expires_at"
+			WHERE "_gotools_locks"."expires_at" < ?
+		`, name, owner, now.Add(sqliteLockLease).UnixMilli(), now.UnixMilli())
+		if err != nil {
+			// another connection is writing, try again
+			if IsTransient(err) {
+				return false, nil
+			}
+			return false, err
+		}
+
+		affected, err := result.RowsAffected()
+		return affected == 1, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(sqliteLockLease / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				conn.ExecContext(context.Background(), `UPDATE "_gotools_locks" SET "expires_at" = ? WHERE "name" = ? AND "owner" = ?`,
+					time.Now().Add(sqliteLockLease).UnixMilli(), name, owner)
+			}
+		}
+	}()
+
+	return func(ctx context.Context) error {
+		close(stop)
+		<-stopped
+
+		_, err := conn.ExecContext(ctx, `DELETE FROM "_gotools_locks" WHERE "name" = ? AND "owner" = ?`, name, owner)
+		return err
+	}, nil
+}
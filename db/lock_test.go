@@ -0,0 +1,221 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/MathiasMantai/gotools/db/mysql"
+	"github.com/MathiasMantai/gotools/db/postgres"
+	"testing"
+	"time"
+)
+
+func TestLockSqlite(t *testing.T) {
+	d := getTestDb(t, DbConnectOptions{})
+	ctx := context.Background()
+
+	lock, err := d.Lock(ctx, "job", 0)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+
+	start := time.Now()
+	if _, err := d.Lock(ctx, "job", 120*time.Millisecond); !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("Expected ErrLockTimeout, got %v", err)
+	}
+	if waited := time.Since(start); waited < 100*time.Millisecond {
+		t.Errorf("Expected Lock to wait for the timeout, returned after %v", waited)
+	}
+
+	other, err := d.Lock(ctx, "other job", 0)
+	if err != nil {
+		t.Fatalf("Expected a different name to be free: %v", err)
+	}
+	other.Unlock(ctx)
+
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Errorf("Expected a second Unlock to do nothing, got %v", err)
+	}
+
+	lock, err = d.Lock(ctx, "job", 0)
+	if err != nil {
+		t.Fatalf("Expected the released lock to be free: %v", err)
+	}
+	defer lock.Unlock(ctx)
+}
+
+func TestLockSqliteExpiredLease(t *testing.T) {
+	d := getTestDb(t, DbConnectOptions{})
+	ctx := context.Background()
+
+	crashed, err := d.Lock(ctx, "job", 0)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	defer crashed.Unlock(ctx)
+
+	// a crashed holder stops renewing its lease
+	if _, err := d.Exec(`UPDATE "_gotools_locks" SET "expires_at" = ?`, time.Now().Add(-time.Second).UnixMilli()); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := d.Lock(ctx, "job", 0)
+	if err != nil {
+		t.Fatalf("Expected the expired lock to be taken over: %v", err)
+	}
+	lock.Unlock(ctx)
+}
+
+func TestLockMysql(t *testing.T) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDb.Close()
+
+	mock.ExpectQuery(`SELECT GET_LOCK\(\?, \?\)`).WithArgs("job", int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"GET_LOCK"}).AddRow(1))
+	mock.ExpectQuery(`SELECT RELEASE_LOCK\(\?\)`).WithArgs("job").
+		WillReturnRows(sqlmock.NewRows([]string{"RELEASE_LOCK"}).AddRow(1))
+	mock.ExpectQuery(`SELECT GET_LOCK\(\?, \?\)`).WithArgs("job", int64(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"GET_LOCK"}).AddRow(0))
+
+	d := &Db{DbObj: &mysql.MySqlDb{DbObj: mockDb}, DbType: "mysql"}
+	ctx := context.Background()
+
+	lock, err := d.Lock(ctx, "job", 1500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+
+	if _, err := d.Lock(ctx, "job", 0); !errors.Is(err, ErrLockTimeout) {
+		t.Errorf("Expected ErrLockTimeout, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestLockPostgres(t *testing.T) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDb.Close()
+
+	key := advisoryLockKey("job")
+	mock.ExpectExec(`set_config\('lock_timeout'`).WithArgs("250ms").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WithArgs(key).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RESET lock_timeout").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT pg_advisory_unlock\(\$1\)`).WithArgs(key).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_advisory_unlock"}).AddRow(true))
+
+	d := &Db{DbObj: &postgres.PgSqlDb{DbObj: mockDb}, DbType: "postgres"}
+	ctx := context.Background()
+
+	lock, err := d.Lock(ctx, "job", 250*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+
+	if advisoryLockKey("job") != key || advisoryLockKey("other") == key {
+		t.Error("Expected advisory lock keys to be stable and distinct")
+	}
+}
+
+func TestLockDiscardsConnectionAfterFailedRelease(t *testing.T) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDb.Close()
+
+	ctx := context.Background()
+
+	// keeps the mock driver open while the lock connection is discarded
+	held, err := mockDb.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Close()
+
+	mock.ExpectQuery(`SELECT GET_LOCK\(\?, \?\)`).WithArgs("job", int64(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"GET_LOCK"}).AddRow(1))
+	mock.ExpectQuery(`SELECT RELEASE_LOCK\(\?\)`).WithArgs("job").WillReturnError(fmt.Errorf("connection reset"))
+	// the connection still holding the lock is closed instead of going back to the pool
+	mock.ExpectClose()
+	mock.ExpectQuery(`SELECT GET_LOCK\(\?, \?\)`).WithArgs("job", int64(0)).
+		WillReturnRows(sqlmock.NewRows([]string{"GET_LOCK"}).AddRow(1))
+	mock.ExpectQuery(`SELECT RELEASE_LOCK\(\?\)`).WithArgs("job").
+		WillReturnRows(sqlmock.NewRows([]string{"RELEASE_LOCK"}).AddRow(1))
+
+	d := &Db{DbObj: &mysql.MySqlDb{DbObj: mockDb}, DbType: "mysql"}
+
+	lock, err := d.Lock(ctx, "job", 0)
+	if err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	if err := lock.Unlock(ctx); err == nil {
+		t.Fatal("Expected the failed release to be returned")
+	}
+
+	lock, err = d.Lock(ctx, "job", 0)
+	if err != nil {
+		t.Fatalf("Expected the lock to be free after the failed release: %v", err)
+	}
+	if err := lock.Unlock(ctx); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestLockPostgresDiscardsConnectionAfterFailedReset(t *testing.T) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDb.Close()
+
+	ctx := context.Background()
+
+	held, err := mockDb.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer held.Close()
+
+	key := advisoryLockKey("job")
+	mock.ExpectExec(`set_config\('lock_timeout'`).WithArgs("250ms").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WithArgs(key).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RESET lock_timeout").WillReturnError(fmt.Errorf("connection reset"))
+	// the advisory lock is held, so the connection must not go back to the pool
+	mock.ExpectClose()
+
+	d := &Db{DbObj: &postgres.PgSqlDb{DbObj: mockDb}, DbType: "postgres"}
+
+	if _, err := d.Lock(ctx, "job", 250*time.Millisecond); err == nil || errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("Expected the failed reset to be returned, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,400 @@
+package db
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/MathiasMantai/gotools/db/dblog"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMigrationTable is the history table of a Migrator without Table
+const DefaultMigrationTable = "_schema_migrations"
+
+// ErrMigrationChanged is returned if the up file of an applied migration differs from the applied one
+var ErrMigrationChanged = errors.New("applied migration was changed")
+
+// migration files are named like 0001_create_users.up.sql and 0001_create_users.down.sql
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// migrationTableCreator is implemented by dialects without CREATE TABLE IF NOT EXISTS or TIMESTAMP
+type migrationTableCreator interface {
+	MigrationTableQuery(table string) string
+}
+
+// scriptSplitter is implemented by dialects which cannot execute a script with several statements at once
+type scriptSplitter interface {
+	SplitScript(script string) ([]string, error)
+}
+
+// FileMigration is a version read from a pair of migration files
+type FileMigration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// HasDown is false if there is no down file. an empty down file reverts nothing
+	HasDown bool
+	// Checksum is the hex encoded sha256 of Up
+	Checksum string
+}
+
+// AppliedMigration is a row of the history table
+type AppliedMigration struct {
+	Version  int64  `db:"version"`
+	Name     string `db:"name"`
+	Checksum string `db:"checksum"`
+}
+
+// Migrator applies versioned SQL file migrations and records them in a history table.
+// every migration runs in its own transaction together with its history row. mysql commits
+// DDL statements implicitly, so a failing migration there may leave its earlier statements applied
+type Migrator struct {
+	// history table, DefaultMigrationTable if empty
+	Table string
+
+	// Up and Down hold a named lock while they run, so concurrent instances of a service
+	// migrate one after the other. LockTimeout defaults to a minute. the lock needs a
+	// connection of its own, NoLock disables it for pools with a single connection
+	LockTimeout time.Duration
+	NoLock      bool
+
+	// receives a message for every migration. nil discards them
+	Logger dblog.Logger
+
+	db         *Db
+	migrations []FileMigration
+}
+
+// NewMigrator reads the migrations in dir of fsys, for example an embed.FS
+func (mdb *Db) NewMigrator(fsys fs.FS, dir string) (*Migrator, error) {
+	migrations, err := LoadFileMigrations(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: mdb, migrations: migrations}, nil
+}
+
+// NewMigratorFromDir reads the migrations in the directory dir
+func (mdb *Db) NewMigratorFromDir(dir string) (*Migrator, error) {
+	return mdb.NewMigrator(os.DirFS(dir), ".")
+}
+
+// LoadFileMigrations reads the files NNNN_name.up.sql and NNNN_name.down.sql in dir of fsys ordered by version.
+// other files are ignored, every version needs an up file and the down file is optional
+func LoadFileMigrations(fsys fs.FS, dir string) ([]FileMigration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading migration directory %s failed: %w", dir, err)
+	}
+
+	byVersion := map[int64]*FileMigration{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration file %s is not named like NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration file %s: %w", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("reading migration file %s failed: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &FileMigration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by %s and %s", version, migration.Name, match[2])
+		}
+
+		// 0001_x.up.sql and 1_x.up.sql are both version 1
+		if (match[3] == "up" && migration.Checksum != "") || (match[3] == "down" && migration.HasDown) {
+			return nil, fmt.Errorf("migration version %d has more than one %s file, %s is a duplicate", version, match[3], entry.Name())
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+			sum := sha256.Sum256(content)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(content)
+			migration.HasDown = true
+		}
+	}
+
+	migrations := make([]FileMigration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Checksum == "" {
+			return nil, fmt.Errorf("migration %d (%s) has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	slices.SortFunc(migrations, func(a, b FileMigration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return migrations, nil
+}
+
+// Migrations returns the migrations read by NewMigrator ordered by version
+func (m *Migrator) Migrations() []FileMigration {
+	return m.migrations
+}
+
+// Up applies the pending migrations in order and returns how many were applied.
+// it fails before applying anything if an applied migration was changed or a pending
+// migration is older than the current version
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	var count int
+	err := m.locked(ctx, func(dialect Dialect) error {
+		applied, err := m.appliedVersions(ctx, dialect)
+		if err != nil {
+			return err
+		}
+
+		var current int64
+		for version := range applied {
+			current = max(current, version)
+		}
+
+		var pending []FileMigration
+		for _, migration := range m.migrations {
+			checksum, ok := applied[migration.Version]
+			switch {
+			case ok && checksum != migration.Checksum:
+				return fmt.Errorf("%w: %d (%s)", ErrMigrationChanged, migration.Version, migration.Name)
+			case ok:
+				continue
+			case migration.Version < current:
+				return fmt.Errorf("migration %d (%s) is older than the current version %d", migration.Version, migration.Name, current)
+			}
+			pending = append(pending, migration)
+		}
+
+		for _, migration := range pending {
+			m.log().Debug("applying migration", "version", migration.Version, "name", migration.Name)
+
+			err := m.db.WithTx(ctx, nil, func(tx DBOrTx) error {
+				if err := execMigrationScript(ctx, tx, dialect, migration.Up); err != nil {
+					return err
+				}
+
+				query := fmt.Sprintf("INSERT INTO %s (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)", dialect.QuoteIdentifier(m.table()))
+				_, err := tx.ExecContext(ctx, Rebind(dialect.Placeholder(), query), migration.Version, migration.Name, migration.Checksum, repositoryNow())
+				return err
+			})
+			if err != nil {
+				m.log().Error("migration failed", "version", migration.Version, "name", migration.Name, "error", err)
+				return fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+			}
+
+			m.log().Info("migration applied", "version", migration.Version, "name", migration.Name)
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Down reverts the applied migrations newer than target, the newest first, and returns how many were reverted.
+// a target of 0 reverts every migration. it fails before reverting anything if one of them has no down file
+func (m *Migrator) Down(ctx context.Context, target int64) (int, error) {
+	var count int
+	err := m.locked(ctx, func(dialect Dialect) error {
+		applied, err := m.appliedVersions(ctx, dialect)
+		if err != nil {
+			return err
+		}
+
+		byVersion := map[int64]FileMigration{}
+		for _, migration := range m.migrations {
+			byVersion[migration.Version] = migration
+		}
+
+		var versions []int64
+		for version := range applied {
+			if version <= target {
+				continue
+			}
+			migration, ok := byVersion[version]
+			if !ok {
+				return fmt.Errorf("applied migration %d has no migration files", version)
+			}
+			if !migration.HasDown {
+				return fmt.Errorf("migration %d (%s) has no down file", migration.Version, migration.Name)
+			}
+			versions = append(versions, version)
+		}
+		slices.Sort(versions)
+		slices.Reverse(versions)
+
+		for _, version := range versions {
+			migration := byVersion[version]
+			m.log().Debug("reverting migration", "version", migration.Version, "name", migration.Name)
+
+			err := m.db.WithTx(ctx, nil, func(tx DBOrTx) error {
+				if err := execMigrationScript(ctx, tx, dialect, migration.Down); err != nil {
+					return err
+				}
+
+				query := fmt.Sprintf("DELETE FROM %s WHERE version = ?", dialect.QuoteIdentifier(m.table()))
+				_, err := tx.ExecContext(ctx, Rebind(dialect.Placeholder(), query), migration.Version)
+				return err
+			})
+			if err != nil {
+				m.log().Error("reverting migration failed", "version", migration.Version, "name", migration.Name, "error", err)
+				return fmt.Errorf("reverting migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+			}
+
+			m.log().Info("migration reverted", "version", migration.Version, "name", migration.Name)
+			count++
+		}
+
+		return nil
+	})
+
+	return count, err
+}
+
+// Applied returns the rows of the history table ordered by version
+func (m *Migrator) Applied(ctx context.Context) ([]AppliedMigration, error) {
+	dialect, err := m.db.Dialect()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.createTable(ctx, dialect); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT version, name, checksum FROM %s ORDER BY version", dialect.QuoteIdentifier(m.table()))
+	return Select[AppliedMigration](Primary(ctx), m.db, query)
+}
+
+// Version returns the newest applied version or 0 if no migration was applied
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.Applied(ctx)
+	if err != nil || len(applied) == 0 {
+		return 0, err
+	}
+
+	return applied[len(applied)-1].Version, nil
+}
+
+// locked runs fn while holding the migration lock
+func (m *Migrator) locked(ctx context.Context, fn func(dialect Dialect) error) error {
+	dialect, err := m.db.Dialect()
+	if err != nil {
+		return err
+	}
+
+	if !m.NoLock {
+		timeout := m.LockTimeout
+		if timeout == 0 {
+			timeout = time.Minute
+		}
+
+		lock, err := m.db.Lock(ctx, "gotools_migrations_"+m.table(), timeout)
+		if err != nil {
+			return err
+		}
+		defer lock.Unlock(context.WithoutCancel(ctx))
+	}
+
+	if err := m.createTable(ctx, dialect); err != nil {
+		return err
+	}
+
+	return fn(dialect)
+}
+
+// appliedVersions returns the checksums of the applied migrations by version
+func (m *Migrator) appliedVersions(ctx context.Context, dialect Dialect) (map[int64]string, error) {
+	query := fmt.Sprintf("SELECT version, name, checksum FROM %s", dialect.QuoteIdentifier(m.table()))
+	applied, err := Select[AppliedMigration](Primary(ctx), m.db, query)
+	if err != nil {
+		return nil, fmt.Errorf("reading migration history failed: %w", err)
+	}
+
+	versions := make(map[int64]string, len(applied))
+	for _, migration := range applied {
+		versions[migration.Version] = migration.Checksum
+	}
+
+	return versions, nil
+}
+
+func (m *Migrator) createTable(ctx context.Context, dialect Dialect) error {
+	query := fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (version BIGINT NOT NULL PRIMARY KEY, name VARCHAR(255) NOT NULL, checksum VARCHAR(64) NOT NULL, applied_at TIMESTAMP NOT NULL)",
+		dialect.QuoteIdentifier(m.table()),
+	)
+	if creator, ok := dialect.(migrationTableCreator); ok {
+		query = creator.MigrationTableQuery(m.table())
+	}
+
+	if _, err := m.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("creating migration table failed: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) table() string {
+	if m.Table == "" {
+		return DefaultMigrationTable
+	}
+
+	return m.Table
+}
+
+func (m *Migrator) log() dblog.Logger {
+	return dblog.Or(m.Logger)
+}
+
+// execMigrationScript executes a migration file as one statement unless the dialect has to split it
+func execMigrationScript(ctx context.Context, tx DBOrTx, dialect Dialect, script string) error {
+	statements := []string{script}
+	if splitter, ok := dialect.(scriptSplitter); ok {
+		var err error
+		if statements, err = splitter.SplitScript(script); err != nil {
+			return err
+		}
+	}
+
+	for _, statement := range statements {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,258 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func migrationFS() fstest.MapFS {
+	return fstest.MapFS{
+		"migrations/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL);")},
+		"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		"migrations/0002_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD COLUMN email TEXT;\nCREATE INDEX users_email ON users (email);")},
+		"migrations/0002_add_email.down.sql":    {Data: []byte("DROP INDEX users_email;\nALTER TABLE users DROP COLUMN email;")},
+		"migrations/README.md":                  {Data: []byte("not a migration")},
+	}
+}
+
+func tableExists(t *testing.T, d *Db, table string) bool {
+	t.Helper()
+
+	var count int
+	if err := d.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+
+	return count > 0
+}
+
+func TestLoadFileMigrations(t *testing.T) {
+	migrations, err := LoadFileMigrations(migrationFS(), "migrations")
+	if err != nil {
+		t.Fatalf("LoadFileMigrations failed: %v", err)
+	}
+
+	if len(migrations) != 2 {
+		t.Fatalf("Expected 2 migrations, got %d", len(migrations))
+	}
+	if migrations[0].Version != 1 || migrations[0].Name != "create_users" || !migrations[0].HasDown {
+		t.Errorf("Unexpected first migration %+v", migrations[0])
+	}
+	if migrations[1].Version != 2 || len(migrations[1].Checksum) != 64 {
+		t.Errorf("Unexpected second migration %+v", migrations[1])
+	}
+
+	invalid := []fstest.MapFS{
+		{"m/0001_a.up.sql": {}, "m/0001_b.up.sql": {}},
+		{"m/0001_a.down.sql": {}},
+		{"m/create.sql": {}},
+		{"m/0001_a.up.sql": {}, "m/1_a.up.sql": {}},
+		{"m/0001_a.up.sql": {}, "m/0001_a.down.sql": {}, "m/01_a.down.sql": {}},
+	}
+	for _, fsys := range invalid {
+		if _, err := LoadFileMigrations(fsys, "m"); err == nil {
+			t.Errorf("Expected an error for %v", fsys)
+		}
+	}
+}
+
+func TestMigratorUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	d := getTestDb(t, DbConnectOptions{})
+
+	migrator, err := d.NewMigrator(migrationFS(), "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up failed: %v", err)
+	}
+	if applied != 2 {
+		t.Errorf("Expected 2 applied migrations, got %d", applied)
+	}
+
+	if _, err := d.Exec("INSERT INTO users (name, email) VALUES ('a', 'a@example.com')"); err != nil {
+		t.Errorf("Expected the email column to exist: %v", err)
+	}
+
+	// applied migrations are not run again
+	applied, err = migrator.Up(ctx)
+	if err != nil || applied != 0 {
+		t.Errorf("Expected no migrations on the second run, got %d and %v", applied, err)
+	}
+
+	history, err := migrator.Applied(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versions := []int64{}
+	for _, migration := range history {
+		versions = append(versions, migration.Version)
+	}
+	if !reflect.DeepEqual(versions, []int64{1, 2}) {
+		t.Errorf("Expected versions [1 2], got %v", versions)
+	}
+
+	reverted, err := migrator.Down(ctx, 1)
+	if err != nil {
+		t.Fatalf("Down failed: %v", err)
+	}
+	if version, _ := migrator.Version(ctx); reverted != 1 || version != 1 {
+		t.Errorf("Expected 1 reverted migration and version 1, got %d and %d", reverted, version)
+	}
+	if _, err := d.Exec("INSERT INTO users (name, email) VALUES ('b', 'b@example.com')"); err == nil {
+		t.Errorf("Expected the email column to be dropped")
+	}
+
+	if _, err := migrator.Down(ctx, 0); err != nil {
+		t.Fatalf("Down to 0 failed: %v", err)
+	}
+	if tableExists(t, d, "users") {
+		t.Errorf("Expected the users table to be dropped")
+	}
+}
+
+func TestMigratorRollsBackFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	d := getTestDb(t, DbConnectOptions{})
+
+	fsys := migrationFS()
+	fsys["migrations/0003_broken.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE broken (id INTEGER);\nINSERT INTO missing VALUES (1);")}
+
+	migrator, err := d.NewMigrator(fsys, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applied, err := migrator.Up(ctx)
+	if err == nil || !strings.Contains(err.Error(), "3 (broken)") {
+		t.Fatalf("Expected migration 3 to fail, got %v", err)
+	}
+	if applied != 2 {
+		t.Errorf("Expected the first 2 migrations to be applied, got %d", applied)
+	}
+
+	if version, _ := migrator.Version(ctx); version != 2 {
+		t.Errorf("Expected version 2, got %d", version)
+	}
+	if tableExists(t, d, "broken") {
+		t.Errorf("Expected the failed migration to be rolled back")
+	}
+}
+
+func TestMigratorRejectsChangedAndOldMigrations(t *testing.T) {
+	ctx := context.Background()
+	d := getTestDb(t, DbConnectOptions{})
+
+	migrator, err := d.NewMigrator(migrationFS(), "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator.Table = "history"
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := migrationFS()
+	changed["migrations/0001_create_users.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY);")}
+	migrator, err = d.NewMigrator(changed, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator.Table = "history"
+	if _, err := migrator.Up(ctx); !errors.Is(err, ErrMigrationChanged) {
+		t.Errorf("Expected ErrMigrationChanged, got %v", err)
+	}
+
+	old := migrationFS()
+	old["migrations/0000_first.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE first (id INTEGER);")}
+	migrator, err = d.NewMigrator(old, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	migrator.Table = "history"
+	if _, err := migrator.Up(ctx); err == nil || !strings.Contains(err.Error(), "older than the current version") {
+		t.Errorf("Expected an error for an old pending migration, got %v", err)
+	}
+}
+
+func TestMigratorDownWithoutDownFile(t *testing.T) {
+	ctx := context.Background()
+	d := getTestDb(t, DbConnectOptions{})
+
+	fsys := migrationFS()
+	delete(fsys, "migrations/0001_create_users.down.sql")
+
+	migrator, err := d.NewMigrator(fsys, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is reverted if a single down file is missing
+	if _, err := migrator.Down(ctx, 0); err == nil {
+		t.Errorf("Expected an error for the missing down file")
+	}
+	if version, _ := migrator.Version(ctx); version != 2 {
+		t.Errorf("Expected version 2, got %d", version)
+	}
+}
+
+func TestMysqlSplitScript(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		expected []string
+	}{
+		{"quoted delimiter", "CREATE TABLE a (x TEXT DEFAULT ';');\n-- b;\nDROP TABLE c;\n", []string{"CREATE TABLE a (x TEXT DEFAULT ';')", "-- b;\nDROP TABLE c"}},
+		{"backslash escape", `INSERT INTO a VALUES ('it\'s; ok', "say \"hi;\"");`, []string{`INSERT INTO a VALUES ('it\'s; ok', "say \"hi;\"")`}},
+		{"doubled quotes", "INSERT INTO a VALUES ('it''s; ok');", []string{"INSERT INTO a VALUES ('it''s; ok')"}},
+		{"hash comment", "# don't split;\nSELECT 1;", []string{"# don't split;\nSELECT 1"}},
+		{"brackets are no quotes", "SELECT '[' AS a; SELECT 2;", []string{"SELECT '[' AS a", "SELECT 2"}},
+		{"double dash needs a space", "SELECT 1--1;\nSELECT 2;", []string{"SELECT 1--1", "SELECT 2"}},
+		{"block comment", "/* a; b */ SELECT 1; SELECT 2", []string{"/* a; b */ SELECT 1", "SELECT 2"}},
+		{"comment only", "SELECT 1;\n-- the end\n", []string{"SELECT 1"}},
+		{
+			"delimiter",
+			"DELIMITER $$\nCREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND$$\nDELIMITER ;\nCALL p();",
+			[]string{"CREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND", "CALL p()"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			statements, err := mysqlDialect{}.SplitScript(test.script)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(statements, test.expected) {
+				t.Errorf("Expected %q, got %q", test.expected, statements)
+			}
+		})
+	}
+
+	for _, script := range []string{"SELECT 'open;", "SELECT 1 /* open", "DELIMITER \nSELECT 1"} {
+		if _, err := (mysqlDialect{}).SplitScript(script); err == nil {
+			t.Errorf("Expected an error for %q", script)
+		}
+	}
+}
+
+func TestMssqlSplitScript(t *testing.T) {
+	batches, err := mssqlDialect{}.SplitScript("CREATE TABLE a (id INT);\nGO\nCREATE PROCEDURE p AS SELECT 1;\n  go  \n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"CREATE TABLE a (id INT);\n", "CREATE PROCEDURE p AS SELECT 1;\n", ""}
+	if !reflect.DeepEqual(batches, expected) {
+		t.Errorf("Expected mssql batches %q, got %q", expected, batches)
+	}
+}
@@ -0,0 +1,51 @@
+package mssql
+
+import (
+	"context"
+	"fmt"
+	mssqldriver "github.com/denisenkom/go-mssqldb"
+	"io"
+)
+
+// CopyIn inserts the rows returned by next with the bulk copy protocol inside of a transaction.
+// next returns io.EOF after the last row
+func (mdb *MssqlDb) CopyIn(ctx context.Context, table string, columns []string, next func() ([]any, error)) (int64, error) {
+	tx, err := mdb.DbObj.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, mssqldriver.CopyIn(table, mssqldriver.BulkOptions{}, columns...))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for {
+		values, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return 0, fmt.Errorf("buffering bulk copy row failed: %w", err)
+		}
+	}
+
+	// executing without arguments sends the buffered rows
+	result, err := stmt.ExecContext(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	copied, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return copied, tx.Commit()
+}
@@ -0,0 +1,47 @@
+package mssql
+
+import (
+	"context"
+	"github.com/DATA-DOG/go-sqlmock"
+	"io"
+	"testing"
+)
+
+func TestCopyIn(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare(`INSERTBULK \{"TableName":"users".*"ColumnsName":\["id","name"\]`)
+	prepare.ExpectExec().WithArgs(1, "a").WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare.ExpectExec().WithArgs(2, "b").WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare.ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	rows := [][]any{{1, "a"}, {2, "b"}}
+	next := func() ([]any, error) {
+		if len(rows) == 0 {
+			return nil, io.EOF
+		}
+		row := rows[0]
+		rows = rows[1:]
+		return row, nil
+	}
+
+	mssqlDb := &MssqlDb{DbObj: db}
+	copied, err := mssqlDb.CopyIn(context.Background(), "users", []string{"id", "name"}, next)
+	if err != nil {
+		t.Fatalf("CopyIn failed: %v", err)
+	}
+
+	if copied != 2 {
+		t.Errorf("Expected 2 copied rows, got %d", copied)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
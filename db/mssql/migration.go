@@ -2,13 +2,25 @@ package mssql
 
 import (
 	"fmt"
-	"github.com/MathiasMantai/gotools/cli"
+	"github.com/MathiasMantai/gotools/db/codegen"
+	"github.com/MathiasMantai/gotools/db/dblog"
+	"path/filepath"
 	"strings"
 )
 
 type MigrationRunner struct {
 	Migrations []Migration
 	Db         *MssqlDb
+
+	// defaults to the logger of Db
+	Logger dblog.Logger
+}
+
+func (mr *MigrationRunner) log() dblog.Logger {
+	if mr.Db == nil {
+		return dblog.Or(mr.Logger)
+	}
+	return dblog.Or(mr.Logger, mr.Db.Logger)
 }
 
 func (m *MigrationRunner) Run() error {
@@ -22,15 +34,14 @@ func (m *MigrationRunner) Run() error {
 	if err != nil {
 		return fmt.Errorf("x> could not determine default database schema: %w", err)
 	}
-	cli.PrintWithTimeAndColor(fmt.Sprintf("=> working in schema: [%s]", schema), "cyan", true)
+	m.log().Debug("working in schema", "schema", schema)
 
 	for key, migration := range m.Migrations {
-		migrationText := fmt.Sprintf("migration %d - %s", key, migration.TableName)
-		cli.PrintWithTimeAndColor("=> attempting to apply "+migrationText, "blue", true)
+		m.log().Debug("attempting to apply migration", "migration", key, "table", migration.TableName)
 
 		applied, err := m.IsMigrationApplied(migration.TableName)
 		if err != nil {
-			cli.PrintWithTimeAndColor("x> error checking whether migration is applied: "+err.Error(), "red", true)
+			m.log().Error("checking whether migration is applied failed", "migration", key, "table", migration.TableName, "error", err)
 			return err
 		}
 
@@ -38,32 +49,32 @@ func (m *MigrationRunner) Run() error {
 			createQuery := migration.CreateQuery()
 			_, err := m.Db.DbObj.Exec(createQuery)
 			if err != nil {
-				cli.PrintWithTimeAndColor(fmt.Sprintf("x> error executing create table for %v: %v", migrationText, err.Error()), "red", true)
+				m.log().Error("executing create table failed", "migration", key, "table", migration.TableName, "error", err)
 				return err
 			}
-			cli.PrintWithTimeAndColor("=> table created or already exists.", "green", true)
+			m.log().Debug("table created or already exists", "table", migration.TableName)
 
 			fkQueries := migration.CreateForeignKeyQueries(schema)
 			if len(fkQueries) > 0 {
-				cli.PrintWithTimeAndColor(fmt.Sprintf("=> applying %d foreign key(s) for %s...", len(fkQueries), migration.TableName), "blue", true)
+				m.log().Debug("applying foreign keys", "table", migration.TableName, "count", len(fkQueries))
 				for i, fkQuery := range fkQueries {
 					_, err := m.Db.DbObj.Exec(fkQuery)
 					if err != nil {
-						cli.PrintWithTimeAndColor(fmt.Sprintf("x> error executing foreign key %d for %v: %v", i+1, migrationText, err.Error()), "red", true)
+						m.log().Error("executing foreign key failed", "table", migration.TableName, "foreign_key", i+1, "error", err)
 						return err
 					}
 				}
-				cli.PrintWithTimeAndColor("=> foreign keys successfully applied.", "green", true)
+				m.log().Debug("foreign keys applied", "table", migration.TableName)
 			}
 
 			err = m.LogMigration(migration.TableName, migration.Description)
 			if err != nil {
 				return err
 			}
-			cli.PrintWithTimeAndColor("=> migration successfully applied and logged.", "green", true)
+			m.log().Info("migration applied", "migration", key, "table", migration.TableName)
 
 		} else {
-			cli.PrintWithTimeAndColor("=> "+migrationText+" already applied. Skipping...", "yellow", true)
+			m.log().Debug("migration already applied", "migration", key, "table", migration.TableName)
 		}
 	}
 
@@ -128,16 +139,65 @@ func (mr *MigrationRunner) SetupMigrationTable() error {
 	return nil
 }
 
+// ConvertToStruct returns a gofmt'd Go file with a struct for the migration at index.
+// the package is named after targetDir and nullable fields are pointers
 func (mr *MigrationRunner) ConvertToStruct(targetDir string, index int, jsonMapping bool) string {
-	var targetMigration Migration = mr.Migrations[index]
+	if index < 0 || index >= len(mr.Migrations) {
+		return "// Error: Invalid migration index"
+	}
+	targetMigration := mr.Migrations[index]
 
-	fields := ""
+	table := codegen.Table{Name: targetMigration.TableName}
 	for _, field := range targetMigration.Fields {
-		fields += fmt.Sprintf("%v %v\n", field.Name, field.DataType)
+		table.Columns = append(table.Columns, codegen.Column{
+			Name:     field.Name,
+			GoType:   MapMssqlTypeToGo(field.DataType),
+			Nullable: field.Nullable,
+		})
+	}
 
+	code, err := codegen.Generate([]codegen.Table{table}, codegen.Options{
+		Package:  codegen.PackageName(filepath.Base(targetDir)),
+		JSONTags: jsonMapping,
+	})
+	if err != nil {
+		return "// Error: " + err.Error()
+	}
+
+	return string(code)
+}
+
+// MapMssqlTypeToGo returns the Go type a column of mssqlType is scanned into
+func MapMssqlTypeToGo(mssqlType string) string {
+	mssqlType = strings.ToLower(strings.TrimSpace(mssqlType))
+	if i := strings.IndexByte(mssqlType, '('); i >= 0 {
+		mssqlType = strings.TrimSpace(mssqlType[:i])
 	}
 
-	return fmt.Sprintf("type %v struct {%v}", targetMigration.TableName, fields)
+	switch mssqlType {
+	case "bit":
+		return "bool"
+	case "tinyint":
+		return "uint8"
+	case "smallint":
+		return "int16"
+	case "int":
+		return "int32"
+	case "bigint":
+		return "int64"
+	case "decimal", "numeric", "money", "smallmoney", "float":
+		return "float64"
+	case "real":
+		return "float32"
+	case "char", "varchar", "nchar", "nvarchar", "text", "ntext", "xml":
+		return "string"
+	case "date", "datetime", "datetime2", "smalldatetime", "datetimeoffset", "time":
+		return "time.Time"
+	case "binary", "varbinary", "image", "timestamp", "rowversion", "uniqueidentifier":
+		return "[]byte"
+	default:
+		return "any"
+	}
 }
 
 func (mr *MigrationRunner) AddMigration(tableName string, fields []MigrationField) {
@@ -148,7 +208,7 @@ func (mr *MigrationRunner) AddMigration(tableName string, fields []MigrationFiel
 }
 
 func (ms *MigrationRunner) LogMigration(tableName string, description string) error {
-	cli.PrintWithTimeAndColor("=> logging migration...", "blue", true)
+	ms.log().Debug("logging migration", "table", tableName)
 
 	var schema string
 	err := ms.Db.DbObj.QueryRow(`
@@ -158,7 +218,7 @@ func (ms *MigrationRunner) LogMigration(tableName string, description string) er
     `).Scan(&schema)
 
 	if err != nil {
-		fmt.Println("Fehler beim Ermitteln des Schemas:", err)
+		ms.log().Error("reading the default schema failed", "error", err)
 		return err
 	}
 
@@ -177,7 +237,7 @@ func (ms *MigrationRunner) LogMigration(tableName string, description string) er
 
 	_, err = ms.Db.DbObj.Exec(createTableQuery)
 	if err != nil {
-		fmt.Println("Fehler beim Erstellen der migrations-Tabelle:", err)
+		ms.log().Error("creating migrations table failed", "error", err)
 		return err
 	}
 
@@ -196,7 +256,7 @@ func (ms *MigrationRunner) LogMigration(tableName string, description string) er
 
 	_, err = ms.Db.DbObj.Exec(insertQuery)
 	if err != nil {
-		fmt.Println("Fehler beim Einfügen des Migration-Eintrags:", err)
+		ms.log().Error("logging migration failed", "table", tableName, "error", err)
 		return err
 	}
 
@@ -3,12 +3,15 @@ package mssql
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"github.com/MathiasMantai/gotools/cli"
+	"github.com/MathiasMantai/gotools/db/dblog"
 	"github.com/MathiasMantai/gotools/db/util"
 	_ "github.com/denisenkom/go-mssqldb"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 type DbConnData struct {
@@ -17,12 +20,35 @@ type DbConnData struct {
 	Database string
 	User     string
 	Pw       string
+	TLS      TLSConfig
+
+	// additional connection string parameters like "app name" or "connection timeout"
+	Params map[string]string
+
+	// receives the messages of the connection and its migration runners. nil discards them
+	Logger dblog.Logger
+}
+
+// TLS settings of a connection.
+// Mode is one of disable, prefer, require, verify-ca or verify-full.
+// go-mssqldb always verifies the host name, so verify-ca behaves like verify-full
+type TLSConfig struct {
+	Mode   string
+	CAFile string
+
+	// client certificates are not supported by go-mssqldb
+	CertFile string
+	KeyFile  string
+
+	// host name expected in the server certificate
+	ServerName string
 }
 
 type MssqlDb struct {
 	DbObj    *sql.DB
 	Qb       *QueryBuilder
 	ConnData DbConnData
+	Logger   dblog.Logger
 	// Scaffolder *Scaffold
 }
 
@@ -42,19 +68,50 @@ func (mdb *MssqlDb) QueryRow(query string, args ...any) *sql.Row {
 	return mdb.DbObj.QueryRow(query, args...)
 }
 
-func Connect(server string, port string, database string, user string, pw string) (*MssqlDb, error) {
-	var db MssqlDb
+func (mdb *MssqlDb) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return mdb.DbObj.ExecContext(ctx, query, args...)
+}
 
-	db.ConnData = DbConnData{
+func (mdb *MssqlDb) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return mdb.DbObj.QueryContext(ctx, query, args...)
+}
+
+func (mdb *MssqlDb) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return mdb.DbObj.QueryRowContext(ctx, query, args...)
+}
+
+func (mdb *MssqlDb) PingContext(ctx context.Context) error {
+	return mdb.DbObj.PingContext(ctx)
+}
+
+// DB returns the underlying connection pool
+func (mdb *MssqlDb) DB() *sql.DB {
+	return mdb.DbObj
+}
+
+func Connect(server string, port string, database string, user string, pw string) (*MssqlDb, error) {
+	return ConnectWithConnData(DbConnData{
 		Server:   server,
 		Port:     port,
 		Database: database,
 		User:     user,
 		Pw:       pw,
+	})
+}
+
+// ConnectWithConnData opens a connection with all available connection settings including tls and connection string parameters
+func ConnectWithConnData(connData DbConnData) (*MssqlDb, error) {
+	var db MssqlDb
+
+	db.ConnData = connData
+	db.Logger = connData.Logger
+
+	connectionString, err := FormatDSN(connData)
+	if err != nil {
+		return nil, err
 	}
 
-	connectionString := fmt.Sprintf("server=%s;user id=%s;password=%s;port=%s;database=%s", db.ConnData.Server, db.ConnData.User, db.ConnData.Pw, db.ConnData.Port, db.ConnData.Database)
-	cli.PrintWithTimeAndColor("=> establishing database connection with database "+database, "green", true)
+	dblog.Or(db.Logger).Info("establishing database connection", "database", connData.Database)
 	dbObj, ConnError := sql.Open("mssql", connectionString)
 	if ConnError != nil {
 		return nil, ConnError
@@ -68,38 +125,87 @@ func Connect(server string, port string, database string, user string, pw string
 	return &db, nil
 }
 
-func (ms *MssqlDb) Migrate(migrationPath string) error {
+// FormatDSN creates an ado style connection string like server=localhost;user id=sa;password=secret;port=1433;database=app
+func FormatDSN(connData DbConnData) (string, error) {
+	connectionString := fmt.Sprintf("server=%s;user id=%s;password=%s;port=%s;database=%s", connData.Server, connData.User, connData.Pw, connData.Port, connData.Database)
 
-	sqlFiles, readDirError := os.ReadDir(migrationPath)
-	if readDirError != nil {
-		fmt.Println("Error reading dir")
-		return readDirError
+	if connData.TLS.CertFile != "" || connData.TLS.KeyFile != "" {
+		return "", errors.New("tls client certificates are not supported for mssql connections")
 	}
 
-	tx, txError := ms.DbObj.Begin()
-	if txError != nil {
-		return txError
+	var tlsParams []string
+	switch connData.TLS.Mode {
+	case "":
+	case "disable":
+		tlsParams = append(tlsParams, "encrypt=disable")
+	case "prefer":
+		tlsParams = append(tlsParams, "encrypt=false")
+	case "require":
+		tlsParams = append(tlsParams, "encrypt=true", "TrustServerCertificate=true")
+	case "verify-ca", "verify-full":
+		tlsParams = append(tlsParams, "encrypt=true", "TrustServerCertificate=false")
+	default:
+		return "", fmt.Errorf("unsupported tls mode %q", connData.TLS.Mode)
 	}
 
-	for _, sqlFile := range sqlFiles {
-		fmt.Printf("=> executing migration %s\n", sqlFile.Name())
-		queryFilePath := filepath.Join(migrationPath, sqlFile.Name())
-		query, readFileError := os.ReadFile(queryFilePath)
-		if readFileError != nil {
-			return readFileError
-		}
+	if connData.TLS.CAFile != "" {
+		tlsParams = append(tlsParams, "certificate="+connData.TLS.CAFile)
+	}
+
+	if connData.TLS.ServerName != "" {
+		tlsParams = append(tlsParams, "hostNameInCertificate="+connData.TLS.ServerName)
+	}
+
+	for _, param := range tlsParams {
+		connectionString += ";" + param
+	}
+
+	keys := make([]string, 0, len(connData.Params))
+	for key := range connData.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-		_, queryError := ms.DbObj.Exec(string(query))
-		if queryError != nil {
-			tx.Rollback()
-			return queryError
+	for _, key := range keys {
+		value := connData.Params[key]
+		if strings.Contains(key+value, ";") {
+			return "", fmt.Errorf("connection string parameter %q must not contain a semicolon", key)
 		}
+		connectionString += fmt.Sprintf(";%s=%s", key, value)
+	}
+
+	return connectionString, nil
+}
+
+// Migrate executes every file in migrationPath in one transaction.
+// nothing is recorded, so the files run again on every call. see db.Migrator for versioned migrations
+func (ms *MssqlDb) Migrate(migrationPath string) error {
 
-		fmt.Printf("=> migration %s executed successfully\n", util.RemoveFileExtension(sqlFile.Name()))
+	sqlFiles, readDirError := os.ReadDir(migrationPath)
+	if readDirError != nil {
+		dblog.Or(ms.Logger).Error("reading migration directory failed", "path", migrationPath, "error", readDirError)
+		return readDirError
 	}
-	tx.Commit()
 
-	return nil
+	return util.WithTx(context.Background(), ms.DbObj, func(tx *sql.Tx) error {
+		for _, sqlFile := range sqlFiles {
+			dblog.Or(ms.Logger).Debug("executing migration", "file", sqlFile.Name())
+			queryFilePath := filepath.Join(migrationPath, sqlFile.Name())
+			query, readFileError := os.ReadFile(queryFilePath)
+			if readFileError != nil {
+				return readFileError
+			}
+
+			_, queryError := tx.Exec(string(query))
+			if queryError != nil {
+				return queryError
+			}
+
+			dblog.Or(ms.Logger).Info("migration executed", "migration", util.RemoveFileExtension(sqlFile.Name()))
+		}
+
+		return nil
+	})
 }
 
 // func (mdb *MssqlDb) SetScaffoldOptions(options ScaffoldOptions) {
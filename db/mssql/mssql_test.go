@@ -0,0 +1,96 @@
+package mssql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatDSN(t *testing.T) {
+	base := "server=sql.local;user id=sa;password=secret;port=1433;database=shop"
+
+	tests := []struct {
+		tls      TLSConfig
+		params   map[string]string
+		expected string
+	}{
+		{
+			expected: base,
+		},
+		{
+			tls:      TLSConfig{Mode: "disable"},
+			expected: base + ";encrypt=disable",
+		},
+		{
+			tls:      TLSConfig{Mode: "require"},
+			expected: base + ";encrypt=true;TrustServerCertificate=true",
+		},
+		{
+			tls:      TLSConfig{Mode: "verify-full", CAFile: "/certs/ca.pem", ServerName: "sql.internal"},
+			expected: base + ";encrypt=true;TrustServerCertificate=false;certificate=/certs/ca.pem;hostNameInCertificate=sql.internal",
+		},
+		{
+			params:   map[string]string{"connection timeout": "30", "app name": "api"},
+			expected: base + ";app name=api;connection timeout=30",
+		},
+	}
+
+	for _, test := range tests {
+		dsn, err := FormatDSN(DbConnData{
+			Server:   "sql.local",
+			Port:     "1433",
+			Database: "shop",
+			User:     "sa",
+			Pw:       "secret",
+			TLS:      test.tls,
+			Params:   test.params,
+		})
+		if err != nil {
+			t.Errorf("FormatDSN failed: %v", err)
+			continue
+		}
+
+		if dsn != test.expected {
+			t.Errorf("Expected dsn %s, got %s", test.expected, dsn)
+		}
+	}
+}
+
+func TestFormatDSNErrors(t *testing.T) {
+	tests := []struct {
+		connData DbConnData
+		contains string
+	}{
+		{DbConnData{TLS: TLSConfig{Mode: "sometimes"}}, "unsupported tls mode"},
+		{DbConnData{TLS: TLSConfig{Mode: "verify-full", CertFile: "client.pem", KeyFile: "client.key"}}, "client certificates are not supported"},
+		{DbConnData{Params: map[string]string{"app name": "a;b"}}, "must not contain a semicolon"},
+	}
+
+	for _, test := range tests {
+		_, err := FormatDSN(test.connData)
+		if err == nil || !strings.Contains(err.Error(), test.contains) {
+			t.Errorf("Expected error containing %q, got %v", test.contains, err)
+		}
+	}
+}
+
+func TestConvertToStruct(t *testing.T) {
+	runner := MigrationRunner{Migrations: []Migration{{
+		TableName: "orders",
+		Fields: []MigrationField{
+			{Name: "id", DataType: "INT"},
+			{Name: "total", DataType: "DECIMAL(10,2)"},
+			{Name: "shipped_at", DataType: "DATETIME2", Nullable: true},
+		},
+	}}}
+
+	code := runner.ConvertToStruct("models", 0, false)
+	for _, expected := range []string{"package models", "import \"time\"", "ID        int32", "Total     float64", "ShippedAt *time.Time"} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected %q in\n%s", expected, code)
+		}
+	}
+
+	if code := runner.ConvertToStruct("models", 3, false); !strings.HasPrefix(code, "// Error") {
+		t.Errorf("Expected an error comment for an invalid index, got %s", code)
+	}
+}
@@ -78,6 +78,7 @@ func (q *QueryBuilder) OrderBy(columns []string) *QueryBuilder {
 			q.Query += ", "
 		}
 	}
+	q.Query += " "
 
 	return q
 }
@@ -87,11 +88,20 @@ func (q *QueryBuilder) GroupBy(columns []string) *QueryBuilder {
 	length := len(columns)
 	for key, column := range columns {
 		q.Query += fmt.Sprintf("%v", column)
-		if key < length {
+		if key < length-1 {
 			q.Query += ", "
 		}
 	}
+	q.Query += " "
+
+	return q
+}
+
+/* OFFSET + FETCH */
 
+// OffsetFetch skips offset rows and returns the next limit rows. it has to follow OrderBy
+func (q *QueryBuilder) OffsetFetch(offset int, limit int) *QueryBuilder {
+	q.Query += fmt.Sprintf("OFFSET %d ROWS FETCH NEXT %d ROWS ONLY ", offset, limit)
 	return q
 }
 
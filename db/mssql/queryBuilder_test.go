@@ -7,3 +7,12 @@ import (
 func TestSelectStatements(t *testing.T) {
 
 }
+
+func TestOffsetFetch(t *testing.T) {
+	query := NewQueryBuilder().SelectAll().From("users").OrderBy([]string{"name", "id"}).OffsetFetch(20, 10).Get()
+
+	expected := "SELECT * FROM users ORDER BY name, id OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"
+	if query != expected {
+		t.Errorf("Expected %q, got %q", expected, query)
+	}
+}
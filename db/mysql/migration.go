@@ -1,8 +1,11 @@
 package mysql
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
-	"github.com/MathiasMantai/gotools/cli"
+	"github.com/MathiasMantai/gotools/db/dblog"
+	"github.com/MathiasMantai/gotools/db/util"
 	"strings"
 )
 
@@ -117,6 +120,16 @@ MIGRATIONRUNNER
 type MigrationRunner struct {
 	Migrations []Migration
 	Db         *MySqlDb
+
+	// defaults to the logger of Db
+	Logger dblog.Logger
+}
+
+func (mr *MigrationRunner) log() dblog.Logger {
+	if mr.Db == nil {
+		return dblog.Or(mr.Logger)
+	}
+	return dblog.Or(mr.Logger, mr.Db.Logger)
 }
 
 func (mr *MigrationRunner) SetupMigrationTable() error {
@@ -158,9 +171,32 @@ func (mr *MigrationRunner) IsMigrationApplied(name string) (bool, error) {
 }
 
 func (mr *MigrationRunner) LogMigration(tableName string, description string) error {
-	cli.PrintWithTimeAndColor("=> logging migration...", "blue", true)
+	mr.log().Debug("logging migration", "table", tableName)
+
+	_, err := mr.Db.DbObj.Exec(migrationLogQuery(tableName, description))
+	if err != nil {
+		mr.log().Error("logging migration failed", "table", tableName, "error", err)
+		return err
+	}
+
+	return nil
+}
 
-	insertQuery := fmt.Sprintf(`
+// LogMigrationTx logs a migration inside tx so it is only recorded if the migration succeeds
+func (mr *MigrationRunner) LogMigrationTx(tx *sql.Tx, tableName string, description string) error {
+	mr.log().Debug("logging migration", "table", tableName)
+
+	_, err := tx.Exec(migrationLogQuery(tableName, description))
+	if err != nil {
+		mr.log().Error("logging migration failed", "table", tableName, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func migrationLogQuery(tableName string, description string) string {
+	return fmt.Sprintf(`
         INSERT INTO _migrations (
             name,
             description,
@@ -172,14 +208,6 @@ func (mr *MigrationRunner) LogMigration(tableName string, description string) er
             CURRENT_TIMESTAMP()
         )
     `, strings.ReplaceAll(tableName, "'", "''"), strings.ReplaceAll(description, "'", "''"))
-
-	_, err := mr.Db.DbObj.Exec(insertQuery)
-	if err != nil {
-		fmt.Println("Fehler beim Einfügen des Migration-Eintrags:", err)
-		return err
-	}
-
-	return nil
 }
 
 func (mr *MigrationRunner) Run() error {
@@ -191,48 +219,46 @@ func (mr *MigrationRunner) Run() error {
 	}
 
 	for key, migration := range mr.Migrations {
-		migrationText := fmt.Sprintf("migration %d - %s", key, migration.TableName)
-		cli.PrintWithTimeAndColor("=> attempting to apply "+migrationText, "blue", true)
+		mr.log().Debug("attempting to apply migration", "migration", key, "table", migration.TableName)
 
 		applied, err := mr.IsMigrationApplied(migration.TableName)
 		if err != nil {
-			cli.PrintWithTimeAndColor(fmt.Sprintf("x> error checking whether migration is applied : %v", err.Error()), "red", true)
+			mr.log().Error("checking whether migration is applied failed", "migration", key, "table", migration.TableName, "error", err)
 			return err
 		}
 
 		if !applied {
-			createQuery := migration.CreateQuery()
-
-			_, err := mr.Db.DbObj.Exec(createQuery)
-			if err != nil {
-				return err
-			}
+			// mysql commits DDL implicitly, the transaction only makes the migration log atomic
+			err := util.WithTx(context.Background(), mr.Db.DbObj, func(tx *sql.Tx) error {
+				if _, err := tx.Exec(migration.CreateQuery()); err != nil {
+					return err
+				}
 
-			fkQueries := migration.CreateForeignKeyQueries()
-			if len(fkQueries) > 0 {
-				cli.PrintWithTimeAndColor(fmt.Sprintf("=> applying %d foreign key(s) for %s...", len(fkQueries), migration.TableName), "blue", true)
-				for i, fkQuery := range fkQueries {
-					_, err := mr.Db.DbObj.Exec(fkQuery)
-					if err != nil {
-						cli.PrintWithTimeAndColor(fmt.Sprintf("x> error executing foreign key %d for %v: %v", i+1, migrationText, err.Error()), "red", true)
-						return err
+				fkQueries := migration.CreateForeignKeyQueries()
+				if len(fkQueries) > 0 {
+					mr.log().Debug("applying foreign keys", "table", migration.TableName, "count", len(fkQueries))
+					for i, fkQuery := range fkQueries {
+						if _, err := tx.Exec(fkQuery); err != nil {
+							mr.log().Error("executing foreign key failed", "table", migration.TableName, "foreign_key", i+1, "error", err)
+							return err
+						}
 					}
+					mr.log().Debug("foreign keys applied", "table", migration.TableName)
 				}
-				cli.PrintWithTimeAndColor("=> forein keys successfully applied", "green", true)
-			}
 
-			err = mr.LogMigration(migration.TableName, migration.Description)
+				return mr.LogMigrationTx(tx, migration.TableName, migration.Description)
+			})
 			if err != nil {
 				return err
 			}
-			cli.PrintWithTimeAndColor("=> migration successfully applied and logged", "green", true)
+			mr.log().Info("migration applied", "migration", key, "table", migration.TableName)
 		} else {
-			cli.PrintWithTimeAndColor(fmt.Sprintf("=> %v already applied. Skipping...", migrationText), "yellow", true)
+			mr.log().Debug("migration already applied", "migration", key, "table", migration.TableName)
 		}
 	}
 
 	if len(mr.Migrations) == 0 {
-		cli.PrintWithTimeAndColor("=> no migrations to apply", "yellow", true)
+		mr.log().Info("no migrations to apply")
 	}
 	return nil
 }
@@ -243,3 +269,57 @@ func CreateMigrationRunner(db *MySqlDb) MigrationRunner {
 		Migrations: []Migration{},
 	}
 }
+
+// MapMysqlTypeToGo returns the Go type a column of mysqlType, as reported by
+// information_schema.columns.column_type, is scanned into
+func MapMysqlTypeToGo(mysqlType string) string {
+	mysqlType = strings.ToLower(strings.TrimSpace(mysqlType))
+	unsigned := strings.Contains(mysqlType, "unsigned")
+
+	baseType := mysqlType
+	if i := strings.IndexAny(baseType, "( "); i >= 0 {
+		baseType = baseType[:i]
+	}
+
+	switch baseType {
+	case "tinyint":
+		if strings.HasPrefix(mysqlType, "tinyint(1)") {
+			return "bool"
+		}
+		if unsigned {
+			return "uint8"
+		}
+		return "int8"
+	case "bool", "boolean":
+		return "bool"
+	case "smallint", "year":
+		if unsigned {
+			return "uint16"
+		}
+		return "int16"
+	case "mediumint", "int", "integer":
+		if unsigned {
+			return "uint32"
+		}
+		return "int32"
+	case "bigint":
+		if unsigned {
+			return "uint64"
+		}
+		return "int64"
+	case "decimal", "numeric", "double", "real":
+		return "float64"
+	case "float":
+		return "float32"
+	case "char", "varchar", "tinytext", "text", "mediumtext", "longtext", "enum", "set", "time":
+		return "string"
+	case "date", "datetime", "timestamp":
+		return "time.Time"
+	case "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "bit":
+		return "[]byte"
+	case "json":
+		return "json.RawMessage"
+	default:
+		return "any"
+	}
+}
@@ -3,14 +3,13 @@ package mysql
 import (
 	"database/sql"
 	// "fmt"
-	"github.com/MathiasMantai/gotools/cli"
 	// "github.com/MathiasMantai/gotools/db"
+	"github.com/MathiasMantai/gotools/db/dblog"
 	"github.com/go-sql-driver/mysql"
 	"net"
 	// "os"
 	// "path/filepath"
 	"context"
-	"time"
 )
 
 type DbConnData struct {
@@ -20,11 +19,19 @@ type DbConnData struct {
 	User     string
 	Pw       string
 	Protocol string
+	TLS      TLSConfig
+
+	// additional dsn parameters. unknown parameters are sent to the server as system variables
+	Params map[string]string
+
+	// receives the messages of the connection and its migration runners. nil discards them
+	Logger dblog.Logger
 }
 
 type MySqlDb struct {
 	DbObj    *sql.DB
 	ConnData DbConnData
+	Logger   dblog.Logger
 }
 
 func (mdb *MySqlDb) BeginTx(ctx context.Context, options *sql.TxOptions) (*sql.Tx, error) {
@@ -43,45 +50,85 @@ func (mdb *MySqlDb) QueryRow(query string, args ...any) *sql.Row {
 	return mdb.DbObj.QueryRow(query, args...)
 }
 
-func Connect(server string, port string, database string, user string, pw string, protocol string) (*MySqlDb, error) {
+func (mdb *MySqlDb) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return mdb.DbObj.ExecContext(ctx, query, args...)
+}
 
-	var (
-		cdb MySqlDb
-	)
+func (mdb *MySqlDb) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return mdb.DbObj.QueryContext(ctx, query, args...)
+}
+
+func (mdb *MySqlDb) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return mdb.DbObj.QueryRowContext(ctx, query, args...)
+}
+
+func (mdb *MySqlDb) PingContext(ctx context.Context) error {
+	return mdb.DbObj.PingContext(ctx)
+}
+
+// DB returns the underlying connection pool
+func (mdb *MySqlDb) DB() *sql.DB {
+	return mdb.DbObj
+}
 
-	cdb.ConnData = DbConnData{
+func Connect(server string, port string, database string, user string, pw string, protocol string) (*MySqlDb, error) {
+	return ConnectWithConnData(DbConnData{
 		Server:   server,
 		Port:     port,
 		Database: database,
 		User:     user,
 		Pw:       pw,
 		Protocol: protocol,
-	}
-	cfg := mysql.Config{
-		User:                 user,
-		Passwd:               pw,
-		Net:                  protocol,
-		Addr:                 net.JoinHostPort(server, port),
-		DBName:               database,
-		AllowNativePasswords: true,
-		ParseTime:            true,
-	}
+	})
+}
 
-	if cfg.Net == "" {
-		cfg.Net = "tcp"
+// ConnectWithConnData opens a connection with all available connection settings including tls and dsn parameters
+func ConnectWithConnData(connData DbConnData) (*MySqlDb, error) {
+
+	var (
+		cdb MySqlDb
+	)
+
+	cdb.ConnData = connData
+	cdb.Logger = connData.Logger
+
+	dsn, err := FormatDSN(connData)
+	if err != nil {
+		return nil, err
 	}
 
-	dsn := cfg.FormatDSN()
-	// fmt.Println(dsn)
 	conn, connError := sql.Open("mysql", dsn)
 	if connError != nil {
 		return nil, connError
 	}
-	cli.PrintWithTimeAndColor("=> successfully connected to database "+database, "green", true)
+	dblog.Or(cdb.Logger).Info("establishing database connection", "database", connData.Database)
 
-	conn.SetConnMaxLifetime(time.Minute * 3)
-	conn.SetMaxOpenConns(10)
-	conn.SetMaxIdleConns(10)
 	cdb.DbObj = conn
 	return &cdb, nil
 }
+
+// FormatDSN creates the data source name for the go-sql-driver from connData.
+// custom tls configurations are registered with the driver under the name returned in the tls parameter
+func FormatDSN(connData DbConnData) (string, error) {
+	cfg := mysql.NewConfig()
+	cfg.User = connData.User
+	cfg.Passwd = connData.Pw
+	cfg.Net = connData.Protocol
+	cfg.Addr = net.JoinHostPort(connData.Server, connData.Port)
+	cfg.DBName = connData.Database
+	cfg.AllowNativePasswords = true
+	cfg.ParseTime = true
+	cfg.Params = connData.Params
+
+	if cfg.Net == "" {
+		cfg.Net = "tcp"
+	}
+
+	tlsConfigName, err := registerTLSConfig(cfg.Addr, connData.TLS)
+	if err != nil {
+		return "", err
+	}
+	cfg.TLSConfig = tlsConfigName
+
+	return cfg.FormatDSN(), nil
+}
@@ -1,7 +1,9 @@
 package mysql
 
 import (
+	"context"
 	// "database/sql"
+	"net/url"
 	"regexp"
 	"testing"
 
@@ -36,3 +38,129 @@ func TestMysqlDb_Exec(t *testing.T) {
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestMysqlDb_ExecContext(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	mysqlDb := &MySqlDb{
+		DbObj: db,
+	}
+	expectedQuery := `DELETE FROM users WHERE id = ?`
+
+	mock.ExpectExec(regexp.QuoteMeta(expectedQuery)).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	result, err := mysqlDb.ExecContext(context.Background(), expectedQuery, 1)
+	require.NoError(t, err)
+
+	rowsAffected, _ := result.RowsAffected()
+	assert.Equal(t, int64(1), rowsAffected)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMysqlDb_QueryContextCanceled(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+	defer db.Close()
+	mysqlDb := &MySqlDb{
+		DbObj: db,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = mysqlDb.QueryContext(ctx, "SELECT name FROM users")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestFormatDSN(t *testing.T) {
+	tests := []struct {
+		connData DbConnData
+		expected string
+	}{
+		{
+			connData: DbConnData{Server: "localhost", Port: "3306", Database: "shop", User: "root", Pw: "secret"},
+			expected: "root:secret@tcp(localhost:3306)/shop?parseTime=true",
+		},
+		{
+			connData: DbConnData{Server: "localhost", Port: "3306", Database: "shop", User: "root", TLS: TLSConfig{Mode: "disable"}},
+			expected: "root@tcp(localhost:3306)/shop?parseTime=true&tls=false",
+		},
+		{
+			connData: DbConnData{Server: "localhost", Port: "3306", Database: "shop", User: "root", TLS: TLSConfig{Mode: "require"}},
+			expected: "root@tcp(localhost:3306)/shop?parseTime=true&tls=skip-verify",
+		},
+		{
+			connData: DbConnData{Server: "localhost", Port: "3306", Database: "shop", User: "root", TLS: TLSConfig{Mode: "verify-full"}},
+			expected: "root@tcp(localhost:3306)/shop?parseTime=true&tls=true",
+		},
+		{
+			connData: DbConnData{Server: "db.local", Port: "3306", Database: "shop", User: "root", TLS: TLSConfig{Mode: "verify-full", ServerName: "mysql.internal"}},
+			expected: "root@tcp(db.local:3306)/shop?parseTime=true&tls=" + url.QueryEscape(tlsConfigName("db.local:3306", "verify-full", TLSConfig{Mode: "verify-full", ServerName: "mysql.internal"})),
+		},
+		{
+			connData: DbConnData{Server: "localhost", Port: "3306", Database: "shop", User: "root", Params: map[string]string{"charset": "utf8mb4", "time_zone": "'+00:00'"}},
+			expected: "root@tcp(localhost:3306)/shop?parseTime=true&charset=utf8mb4&time_zone=%27%2B00%3A00%27",
+		},
+	}
+
+	for _, test := range tests {
+		dsn, err := FormatDSN(test.connData)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, dsn)
+	}
+}
+
+func TestTLSConfigNamePerSettings(t *testing.T) {
+	first, err := FormatDSN(DbConnData{Server: "db.local", Port: "3306", TLS: TLSConfig{Mode: "verify-full", ServerName: "primary.internal"}})
+	require.NoError(t, err)
+	second, err := FormatDSN(DbConnData{Server: "db.local", Port: "3306", TLS: TLSConfig{Mode: "verify-full", ServerName: "replica.internal"}})
+	require.NoError(t, err)
+
+	// the second connection must not replace the config of the first one
+	assert.NotEqual(t, first, second)
+
+	again, err := FormatDSN(DbConnData{Server: "db.local", Port: "3306", TLS: TLSConfig{Mode: "verify-full", ServerName: "primary.internal"}})
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+}
+
+func TestFormatDSNErrors(t *testing.T) {
+	_, err := FormatDSN(DbConnData{Server: "localhost", Port: "3306", TLS: TLSConfig{Mode: "sometimes"}})
+	assert.ErrorContains(t, err, "unsupported tls mode")
+
+	_, err = FormatDSN(DbConnData{Server: "localhost", Port: "3306", TLS: TLSConfig{Mode: "verify-full", CertFile: "client.pem"}})
+	assert.ErrorContains(t, err, "certificate and key file are both required")
+
+	_, err = FormatDSN(DbConnData{Server: "localhost", Port: "3306", TLS: TLSConfig{Mode: "verify-ca", CAFile: "does-not-exist.pem"}})
+	assert.ErrorContains(t, err, "reading tls ca file failed")
+
+	_, err = FormatDSN(DbConnData{Server: "localhost", Port: "3306", TLS: TLSConfig{Mode: "require", CAFile: "ca.pem"}})
+	assert.ErrorContains(t, err, "use verify-ca or verify-full")
+
+	_, err = FormatDSN(DbConnData{Server: "localhost", Port: "3306", TLS: TLSConfig{Mode: "prefer", ServerName: "mysql.internal"}})
+	assert.ErrorContains(t, err, "tls mode prefer cannot be combined")
+
+	_, err = FormatDSN(DbConnData{Server: "localhost", Port: "3306", TLS: TLSConfig{Mode: "allow"}})
+	assert.ErrorContains(t, err, "unsupported tls mode")
+}
+
+func TestMapMysqlTypeToGo(t *testing.T) {
+	tests := map[string]string{
+		"tinyint(1)":       "bool",
+		"tinyint unsigned": "uint8",
+		"int(11)":          "int32",
+		"bigint unsigned":  "uint64",
+		"varchar(255)":     "string",
+		"datetime":         "time.Time",
+		"json":             "json.RawMessage",
+		"geometry":         "any",
+	}
+
+	for mysqlType, expected := range tests {
+		if result := MapMysqlTypeToGo(mysqlType); result != expected {
+			t.Errorf("MapMysqlTypeToGo(%q): expected %s, got %s", mysqlType, expected, result)
+		}
+	}
+}
@@ -70,10 +70,11 @@ func (q *QueryBuilder) OrderBy(columns []string) *QueryBuilder {
 	length := len(columns)
 	for key, column := range columns {
 		q.Query += fmt.Sprintf("%v", column)
-		if key < length {
+		if key < length-1 {
 			q.Query += ", "
 		}
 	}
+	q.Query += " "
 
 	return q
 }
@@ -83,10 +84,25 @@ func (q *QueryBuilder) GroupBy(columns []string) *QueryBuilder {
 	length := len(columns)
 	for key, column := range columns {
 		q.Query += fmt.Sprintf("%v", column)
-		if key < length {
+		if key < length-1 {
 			q.Query += ", "
 		}
 	}
+	q.Query += " "
 
 	return q
 }
+
+/* LIMIT */
+
+// Limit restricts the result to limit rows
+func (q *QueryBuilder) Limit(limit int) *QueryBuilder {
+	q.Query += fmt.Sprintf("LIMIT %d ", limit)
+	return q
+}
+
+// Offset skips offset rows. it has to follow Limit
+func (q *QueryBuilder) Offset(offset int) *QueryBuilder {
+	q.Query += fmt.Sprintf("OFFSET %d ", offset)
+	return q
+}
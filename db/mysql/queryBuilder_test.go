@@ -7,3 +7,12 @@ import (
 func TestSelectStatements(t *testing.T) {
 
 }
+
+func TestLimit(t *testing.T) {
+	query := NewQueryBuilder().SelectAll().From("users").OrderBy([]string{"name", "id"}).Limit(10).Offset(20).Get()
+
+	expected := "SELECT * FROM users ORDER BY name, id LIMIT 10 OFFSET 20"
+	if query != expected {
+		t.Errorf("Expected %q, got %q", expected, query)
+	}
+}
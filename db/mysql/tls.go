@@ -0,0 +1,146 @@
+package mysql
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"os"
+)
+
+// TLS settings of a connection.
+// Mode is one of disable, prefer, require, verify-ca or verify-full. prefer cannot be combined with
+// other settings since only the builtin config of the driver falls back to an unencrypted connection.
+// require does not verify the server, so a CAFile needs verify-ca or verify-full
+type TLSConfig struct {
+	Mode       string
+	CAFile     string
+	CertFile   string
+	KeyFile    string
+	ServerName string
+}
+
+func (t TLSConfig) hasCustomSettings() bool {
+	return t.CAFile != "" || t.CertFile != "" || t.KeyFile != "" || t.ServerName != ""
+}
+
+// registerTLSConfig translates the tls settings into the value of the tls dsn parameter.
+// settings which cannot be expressed by the builtin values are registered as a custom config named after
+// addr and the settings, so connections to the same server with different settings do not replace each other
+func registerTLSConfig(addr string, settings TLSConfig) (string, error) {
+	mode := settings.Mode
+	if mode == "" {
+		if !settings.hasCustomSettings() {
+			return "", nil
+		}
+		mode = "verify-full"
+	}
+
+	switch mode {
+	case "disable":
+		return "false", nil
+	case "prefer":
+		if settings.hasCustomSettings() {
+			return "", errors.New("tls mode prefer cannot be combined with a ca, client certificate or server name")
+		}
+		return "preferred", nil
+	case "require":
+		if settings.CAFile != "" {
+			return "", errors.New("tls mode require does not verify the server, use verify-ca or verify-full with a ca file")
+		}
+		if !settings.hasCustomSettings() {
+			return "skip-verify", nil
+		}
+	case "verify-full":
+		if !settings.hasCustomSettings() {
+			return "true", nil
+		}
+	case "verify-ca":
+	default:
+		return "", fmt.Errorf("unsupported tls mode %q", settings.Mode)
+	}
+
+	tlsConfig, err := buildTLSConfig(mode, settings)
+	if err != nil {
+		return "", err
+	}
+
+	name := tlsConfigName(addr, mode, settings)
+	if err := mysql.RegisterTLSConfig(name, tlsConfig); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// tlsConfigName derives the name of a registered config from addr and a hash of the settings
+func tlsConfigName(addr string, mode string, settings TLSConfig) string {
+	hash := sha256.New()
+	for _, value := range []string{mode, settings.CAFile, settings.CertFile, settings.KeyFile, settings.ServerName} {
+		// the zero byte separates the values since it cannot be part of a path or host name
+		hash.Write([]byte(value))
+		hash.Write([]byte{0})
+	}
+
+	return "gotools-" + addr + "-" + hex.EncodeToString(hash.Sum(nil)[:8])
+}
+
+func buildTLSConfig(mode string, settings TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName: settings.ServerName,
+	}
+
+	if settings.CAFile != "" {
+		pem, err := os.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading tls ca file failed: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls ca file %s contains no certificates", settings.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		if settings.CertFile == "" || settings.KeyFile == "" {
+			return nil, errors.New("tls client certificate and key file are both required")
+		}
+
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading tls client certificate failed: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	switch mode {
+	case "require":
+		tlsConfig.InsecureSkipVerify = true
+	case "verify-ca":
+		// verify the certificate chain but not the host name
+		tlsConfig.InsecureSkipVerify = true
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return errors.New("server did not present a certificate")
+			}
+
+			intermediates := x509.NewCertPool()
+			for _, cert := range state.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+
+			_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+				Roots:         tlsConfig.RootCAs,
+				Intermediates: intermediates,
+			})
+			return err
+		}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,254 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// PlaceholderStyle is the syntax a database uses for positional query parameters
+type PlaceholderStyle int
+
+const (
+	// ? used by mysql and sqlite
+	PlaceholderQuestion PlaceholderStyle = iota
+	// $1, $2, ... used by postgres
+	PlaceholderDollar
+	// @p1, @p2, ... used by mssql
+	PlaceholderAtP
+)
+
+// Placeholder returns the n-th (starting at 1) placeholder in the given style
+func (p PlaceholderStyle) Placeholder(n int) string {
+	switch p {
+	case PlaceholderDollar:
+		return "$" + strconv.Itoa(n)
+	case PlaceholderAtP:
+		return "@p" + strconv.Itoa(n)
+	default:
+		return "?"
+	}
+}
+
+// Placeholder returns the n-th (starting at 1) placeholder of the connected database type
+func (mdb *Db) Placeholder(n int) string {
+	return placeholderStyle(mdb.DbType).Placeholder(n)
+}
+
+// Rebind converts the ? placeholders of query into the placeholder style of the connected database type
+func (mdb *Db) Rebind(query string) string {
+	return Rebind(placeholderStyle(mdb.DbType), query)
+}
+
+// BindNamed replaces the :name parameters of query with placeholders of the connected database type
+// and returns the arguments in matching order. see BindNamed
+func (mdb *Db) BindNamed(query string, arg any) (string, []any, error) {
+	return BindNamed(placeholderStyle(mdb.DbType), query, arg)
+}
+
+// NamedExecContext executes a query with :name parameters bound from arg
+func (mdb *Db) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	return namedExec(ctx, mdb, placeholderStyle(mdb.DbType), query, arg)
+}
+
+// NamedQueryContext runs a query with :name parameters bound from arg
+func (mdb *Db) NamedQueryContext(ctx context.Context, query string, arg any) (*sql.Rows, error) {
+	return namedQuery(ctx, mdb, placeholderStyle(mdb.DbType), query, arg)
+}
+
+// BindNamed replaces the :name parameters of query with placeholders of the transaction's database type
+func (t *Tx) BindNamed(query string, arg any) (string, []any, error) {
+	return BindNamed(placeholderStyle(t.DbType), query, arg)
+}
+
+// NamedExecContext executes a query with :name parameters bound from arg
+func (t *Tx) NamedExecContext(ctx context.Context, query string, arg any) (sql.Result, error) {
+	return namedExec(ctx, t, placeholderStyle(t.DbType), query, arg)
+}
+
+// NamedQueryContext runs a query with :name parameters bound from arg
+func (t *Tx) NamedQueryContext(ctx context.Context, query string, arg any) (*sql.Rows, error) {
+	return namedQuery(ctx, t, placeholderStyle(t.DbType), query, arg)
+}
+
+func namedExec(ctx context.Context, dbOrTx DBOrTx, style PlaceholderStyle, query string, arg any) (sql.Result, error) {
+	boundQuery, args, err := BindNamed(style, query, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return dbOrTx.ExecContext(ctx, boundQuery, args...)
+}
+
+func namedQuery(ctx context.Context, dbOrTx DBOrTx, style PlaceholderStyle, query string, arg any) (*sql.Rows, error) {
+	boundQuery, args, err := BindNamed(style, query, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return dbOrTx.QueryContext(ctx, boundQuery, args...)
+}
+
+// BindNamed replaces the :name parameters of query with placeholders in the given style and returns
+// the values in matching order. arg is either a map with string keys or a struct whose fields
+// are matched like in Select. string literals, quoted identifiers, comments and :: casts are left untouched
+func BindNamed(style PlaceholderStyle, query string, arg any) (string, []any, error) {
+	boundQuery, names := compileQuery(style, query, true)
+
+	lookup, err := namedArgLookup(arg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	args := make([]any, 0, len(names))
+	for _, name := range names {
+		value, ok := lookup(name)
+		if !ok {
+			return "", nil, fmt.Errorf("missing value for named parameter :%s", name)
+		}
+		args = append(args, value)
+	}
+
+	return boundQuery, args, nil
+}
+
+// Rebind converts the ? placeholders of query into the given style
+func Rebind(style PlaceholderStyle, query string) string {
+	if style == PlaceholderQuestion {
+		return query
+	}
+
+	boundQuery, _ := compileQuery(style, query, false)
+	return boundQuery
+}
+
+// namedArgLookup returns a function resolving parameter names against a map or struct
+func namedArgLookup(arg any) (func(name string) (any, bool), error) {
+	v := reflect.ValueOf(arg)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	switch {
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		return func(name string) (any, bool) {
+			value := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !value.IsValid() {
+				return nil, false
+			}
+			return value.Interface(), true
+		}, nil
+	case v.Kind() == reflect.Struct:
+		fieldMap := structFieldMap(v.Type())
+		return func(name string) (any, bool) {
+			index, ok := fieldMap[strings.ToLower(name)]
+			if !ok {
+				return nil, false
+			}
+
+			field, err := v.FieldByIndexErr(index)
+			if err != nil {
+				// field of a nil embedded pointer
+				return nil, true
+			}
+			return field.Interface(), true
+		}, nil
+	default:
+		return nil, fmt.Errorf("named parameters have to be bound from a map or struct, got %T", arg)
+	}
+}
+
+// compileQuery rewrites the parameters of query into placeholders of the given style.
+// with named set :name parameters are replaced and their names returned, otherwise ? placeholders are renumbered
+func compileQuery(style PlaceholderStyle, query string, named bool) (string, []string) {
+	var sb strings.Builder
+	var names []string
+	n := 0
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+
+		switch {
+		case c == '\'' || c == '"' || c == '`' || (c == '[' && style == PlaceholderAtP):
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := strings.IndexByte(query[i+1:], closing)
+			if end < 0 {
+				sb.WriteString(query[i:])
+				return sb.String(), names
+			}
+			sb.WriteString(query[i : i+end+2])
+			i += end + 1
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				sb.WriteString(query[i:])
+				return sb.String(), names
+			}
+			sb.WriteString(query[i : i+end])
+			i += end - 1
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				sb.WriteString(query[i:])
+				return sb.String(), names
+			}
+			sb.WriteString(query[i : i+end+4])
+			i += end + 3
+		case c == '$' && style == PlaceholderDollar && dollarQuoteTag(query[i:]) != "":
+			tag := dollarQuoteTag(query[i:])
+			end := strings.Index(query[i+len(tag):], tag)
+			if end < 0 {
+				sb.WriteString(query[i:])
+				return sb.String(), names
+			}
+			sb.WriteString(query[i : i+len(tag)+end+len(tag)])
+			i += len(tag) + end + len(tag) - 1
+		case named && c == ':' && i+1 < len(query) && query[i+1] == ':':
+			sb.WriteString("::")
+			i++
+		case named && c == ':' && i+1 < len(query) && isNameStart(query[i+1]):
+			end := i + 1
+			for end < len(query) && isNamePart(query[end]) {
+				end++
+			}
+			n++
+			names = append(names, query[i+1:end])
+			sb.WriteString(style.Placeholder(n))
+			i = end - 1
+		case !named && c == '?':
+			n++
+			sb.WriteString(style.Placeholder(n))
+		default:
+			sb.WriteByte(c)
+		}
+	}
+
+	return sb.String(), names
+}
+
+// dollarQuoteTag returns the opening tag of a postgres dollar quoted string like $$ or $body$
+func dollarQuoteTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '$' {
+			return s[:i+1]
+		}
+		if !isNamePart(s[i]) || (i == 1 && !isNameStart(s[i])) {
+			return ""
+		}
+	}
+	return ""
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNamePart(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,119 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBindNamedStyles(t *testing.T) {
+	query := "SELECT * FROM users WHERE name = :name AND age > :age"
+	arg := map[string]any{"name": "max", "age": 30}
+
+	tests := map[PlaceholderStyle]string{
+		PlaceholderQuestion: "SELECT * FROM users WHERE name = ? AND age > ?",
+		PlaceholderDollar:   "SELECT * FROM users WHERE name = $1 AND age > $2",
+		PlaceholderAtP:      "SELECT * FROM users WHERE name = @p1 AND age > @p2",
+	}
+
+	for style, expected := range tests {
+		boundQuery, args, err := BindNamed(style, query, arg)
+		if err != nil {
+			t.Fatalf("BindNamed failed: %v", err)
+		}
+
+		if boundQuery != expected {
+			t.Errorf("Expected %q, got %q", expected, boundQuery)
+		}
+
+		if !reflect.DeepEqual(args, []any{"max", 30}) {
+			t.Errorf("Expected args [max 30], got %v", args)
+		}
+	}
+}
+
+func TestBindNamedSkipsLiteralsCommentsAndCasts(t *testing.T) {
+	query := `SELECT ':not_a_param', "col:x", created_at::date -- :comment
+		FROM t /* :block */ WHERE id = :id AND body = $tag$ :dollar $tag$ AND x = :id`
+
+	boundQuery, args, err := BindNamed(PlaceholderDollar, query, map[string]any{"id": 7})
+	if err != nil {
+		t.Fatalf("BindNamed failed: %v", err)
+	}
+
+	expected := `SELECT ':not_a_param', "col:x", created_at::date -- :comment
+		FROM t /* :block */ WHERE id = $1 AND body = $tag$ :dollar $tag$ AND x = $2`
+	if boundQuery != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, boundQuery)
+	}
+
+	if !reflect.DeepEqual(args, []any{7, 7}) {
+		t.Errorf("Expected args [7 7], got %v", args)
+	}
+}
+
+func TestBindNamedMssqlBrackets(t *testing.T) {
+	boundQuery, _, err := BindNamed(PlaceholderAtP, "SELECT [a:b] FROM t WHERE id = :id", map[string]any{"id": 1})
+	if err != nil {
+		t.Fatalf("BindNamed failed: %v", err)
+	}
+
+	if boundQuery != "SELECT [a:b] FROM t WHERE id = @p1" {
+		t.Errorf("Unexpected query %q", boundQuery)
+	}
+}
+
+func TestBindNamedStruct(t *testing.T) {
+	type params struct {
+		UserName string `db:"name"`
+		MinAge   int
+	}
+
+	boundQuery, args, err := BindNamed(PlaceholderQuestion, "WHERE name = :name AND age >= :min_age", params{UserName: "erika", MinAge: 18})
+	if err != nil {
+		t.Fatalf("BindNamed failed: %v", err)
+	}
+
+	if boundQuery != "WHERE name = ? AND age >= ?" || !reflect.DeepEqual(args, []any{"erika", 18}) {
+		t.Errorf("Unexpected result %q %v", boundQuery, args)
+	}
+}
+
+func TestBindNamedErrors(t *testing.T) {
+	_, _, err := BindNamed(PlaceholderQuestion, "WHERE id = :id", map[string]any{})
+	if err == nil || !strings.Contains(err.Error(), ":id") {
+		t.Errorf("Expected missing parameter error, got %v", err)
+	}
+
+	_, _, err = BindNamed(PlaceholderQuestion, "WHERE id = :id", 5)
+	if err == nil {
+		t.Error("Expected an error for an unsupported argument type")
+	}
+}
+
+func TestRebind(t *testing.T) {
+	result := Rebind(PlaceholderAtP, "SELECT '?' FROM t WHERE a = ? AND b = ?")
+	if result != "SELECT '?' FROM t WHERE a = @p1 AND b = @p2" {
+		t.Errorf("Unexpected query %q", result)
+	}
+}
+
+func TestNamedExecContext(t *testing.T) {
+	d := setupTxTestTable(t)
+	ctx := context.Background()
+
+	_, err := d.NamedExecContext(ctx, "INSERT INTO items (name) VALUES (:name)", map[string]any{"name": "named"})
+	if err != nil {
+		t.Fatalf("NamedExecContext failed: %v", err)
+	}
+
+	names, err := Select[string](ctx, d, "SELECT name FROM items")
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+
+	if len(names) != 1 || names[0] != "named" {
+		t.Errorf("Expected [named], got %v", names)
+	}
+}
@@ -0,0 +1,370 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCursor is returned for cursors that were not created by PaginateKeyset for the same sort columns
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// SortColumn is a column of the ORDER BY clause of a paginated query
+type SortColumn struct {
+	Column string
+	Desc   bool
+}
+
+// Page is a page of an offset paginated query
+type Page[T any] struct {
+	Items []T
+	// 1 based number of the page
+	Page       int
+	PageSize   int
+	Total      int64
+	TotalPages int
+}
+
+// KeysetPage is a page of a keyset paginated query
+type KeysetPage[T any] struct {
+	Items []T
+	// NextCursor continues after the last item. it is empty on the last page
+	NextCursor string
+}
+
+// limiter is implemented by dialects without LIMIT and OFFSET
+type limiter interface {
+	LimitClause(limit int, offset int) string
+}
+
+// Paginate runs query, a SELECT with ? placeholders and without ORDER BY, as a subquery ordered by orderBy
+// and returns the page with the 1 based number page. Total counts all rows of query.
+// the sort columns have to be returned by query and should end with a unique column for a stable order
+func Paginate[T any](ctx context.Context, dbOrTx DBOrTx, query string, orderBy []SortColumn, page int, pageSize int, args ...any) (*Page[T], error) {
+	if pageSize <= 0 {
+		return nil, fmt.Errorf("page size has to be positive, got %d", pageSize)
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	dialect, err := dialectOf(dbOrTx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Page[T]{Items: []T{}, Page: page, PageSize: pageSize}
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM (%s) %s", query, dialect.QuoteIdentifier("paginated"))
+	if err := dbOrTx.QueryRowContext(ctx, Rebind(dialect.Placeholder(), countQuery), args...).Scan(&result.Total); err != nil {
+		return nil, fmt.Errorf("counting rows failed: %w", err)
+	}
+	result.TotalPages = int((result.Total + int64(pageSize) - 1) / int64(pageSize))
+
+	offset := (page - 1) * pageSize
+	if int64(offset) >= result.Total {
+		return result, nil
+	}
+
+	pageQuery, err := paginatedQuery(dialect, query, "", orderBy, pageSize, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Items, err = Select[T](ctx, dbOrTx, Rebind(dialect.Placeholder(), pageQuery), args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// PaginateKeyset runs query like Paginate and returns up to limit rows after cursor, an empty cursor
+// starts at the first row. instead of skipping rows the query continues after the sort values of
+// the last row which stays fast for deep pages and does not skip or repeat rows on concurrent writes.
+// the sort columns have to be mapped to fields of T, must not be NULL and together have to be unique
+func PaginateKeyset[T any](ctx context.Context, dbOrTx DBOrTx, query string, orderBy []SortColumn, cursor string, limit int, args ...any) (*KeysetPage[T], error) {
+	if limit <= 0 {
+		return nil, fmt.Errorf("limit has to be positive, got %d", limit)
+	}
+	if len(orderBy) == 0 {
+		return nil, errors.New("keyset pagination requires at least one sort column")
+	}
+
+	dialect, err := dialectOf(dbOrTx)
+	if err != nil {
+		return nil, err
+	}
+
+	where := ""
+	queryArgs := args
+	if cursor != "" {
+		values, err := DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) != len(orderBy) {
+			return nil, fmt.Errorf("%w: %d values for %d sort columns", ErrInvalidCursor, len(values), len(orderBy))
+		}
+
+		var keyArgs []any
+		where, keyArgs = keysetCondition(dialect, orderBy, values)
+		queryArgs = append(append([]any{}, args...), keyArgs...)
+	}
+
+	// one more row tells if there is a next page
+	pageQuery, err := paginatedQuery(dialect, query, where, orderBy, limit+1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := Select[T](ctx, dbOrTx, Rebind(dialect.Placeholder(), pageQuery), queryArgs...)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &KeysetPage[T]{Items: items}
+	if len(items) <= limit {
+		return result, nil
+	}
+
+	result.Items = items[:limit]
+	values, err := sortValues(result.Items[limit-1], orderBy)
+	if err != nil {
+		return nil, err
+	}
+
+	result.NextCursor, err = EncodeCursor(values...)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// dialectOf returns the dialect of a *Db or *Tx
+func dialectOf(dbOrTx DBOrTx) (Dialect, error) {
+	switch conn := dbOrTx.(type) {
+	case *Db:
+		return conn.Dialect()
+	case *Tx:
+		return GetDialect(conn.DbType)
+	default:
+		return nil, fmt.Errorf("cannot determine the database type of %T, use a *Db or *Tx", dbOrTx)
+	}
+}
+
+// paginatedQuery wraps query into a subquery with an optional condition, the ORDER BY clause and the limit
+func paginatedQuery(dialect Dialect, query string, where string, orderBy []SortColumn, limit int, offset int) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("SELECT * FROM (")
+	sb.WriteString(query)
+	sb.WriteString(") ")
+	sb.WriteString(dialect.QuoteIdentifier("paginated"))
+
+	if where != "" {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(where)
+	}
+
+	if len(orderBy) > 0 {
+		sb.WriteString(" ORDER BY ")
+		for i, sort := range orderBy {
+			if sort.Column == "" {
+				return "", errors.New("sort column without name")
+			}
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(dialect.QuoteIdentifier(sort.Column))
+			if sort.Desc {
+				sb.WriteString(" DESC")
+			}
+		}
+	}
+
+	if l, ok := dialect.(limiter); ok {
+		if len(orderBy) == 0 {
+			// OFFSET ... FETCH is only allowed after ORDER BY
+			sb.WriteString(" ORDER BY (SELECT NULL)")
+		}
+		sb.WriteString(" ")
+		sb.WriteString(l.LimitClause(limit, offset))
+		return sb.String(), nil
+	}
+
+	fmt.Fprintf(&sb, " LIMIT %d", limit)
+	if offset > 0 {
+		fmt.Fprintf(&sb, " OFFSET %d", offset)
+	}
+
+	return sb.String(), nil
+}
+
+// keysetCondition selects the rows after values in the order of orderBy with ? placeholders.
+// (a, b) > (x, y) is expanded to a > x OR (a = x AND b > y) because row values are not supported
+// by every database and cannot mix directions
+func keysetCondition(dialect Dialect, orderBy []SortColumn, values []any) (string, []any) {
+	var conditions []string
+	var args []any
+
+	for i, sort := range orderBy {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, dialect.QuoteIdentifier(orderBy[j].Column)+" = ?")
+			args = append(args, values[j])
+		}
+
+		operator := " > ?"
+		if sort.Desc {
+			operator = " < ?"
+		}
+		parts = append(parts, dialect.QuoteIdentifier(sort.Column)+operator)
+		args = append(args, values[i])
+
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// sortValues reads the values of the sort columns from a struct or a single column T
+func sortValues(item any, orderBy []SortColumn) ([]any, error) {
+	v := reflect.ValueOf(item)
+	if !isStructMapping(v.Type()) {
+		if len(orderBy) != 1 {
+			return nil, fmt.Errorf("keyset pagination of %s supports a single sort column", v.Type())
+		}
+		return []any{item}, nil
+	}
+
+	fieldMap := structFieldMap(v.Type())
+	values := make([]any, 0, len(orderBy))
+	for _, sort := range orderBy {
+		index, ok := fieldMap[strings.ToLower(sort.Column)]
+		if !ok {
+			return nil, fmt.Errorf("sort column %s has no field in %s", sort.Column, v.Type())
+		}
+		values = append(values, fieldValue(v, index))
+	}
+
+	return values, nil
+}
+
+// cursorValue keeps the type of a value through json
+type cursorValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+// EncodeCursor encodes values into an opaque url safe cursor. supported are integers, floats,
+// strings, bools, byte slices, time.Time and driver.Valuer types returning one of them
+func EncodeCursor(values ...any) (string, error) {
+	encoded := make([]cursorValue, 0, len(values))
+	for _, value := range values {
+		cv, err := newCursorValue(value)
+		if err != nil {
+			return "", err
+		}
+		encoded = append(encoded, cv)
+	}
+
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// DecodeCursor returns the values of a cursor created by EncodeCursor
+func DecodeCursor(cursor string) ([]any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	var encoded []cursorValue
+	if err := json.Unmarshal(data, &encoded); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	values := make([]any, 0, len(encoded))
+	for _, cv := range encoded {
+		value, err := cv.decode()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+func newCursorValue(value any) (cursorValue, error) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		resolved, err := valuer.Value()
+		if err != nil {
+			return cursorValue{}, err
+		}
+		value = resolved
+	}
+
+	switch v := value.(type) {
+	case time.Time:
+		return cursorValue{Type: "time", Value: v.Format(time.RFC3339Nano)}, nil
+	case []byte:
+		return cursorValue{Type: "bytes", Value: base64.StdEncoding.EncodeToString(v)}, nil
+	case nil:
+		return cursorValue{}, errors.New("sort columns used for keyset pagination must not be NULL")
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Pointer:
+		if rv.IsNil() {
+			return cursorValue{}, errors.New("sort columns used for keyset pagination must not be NULL")
+		}
+		return newCursorValue(rv.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return cursorValue{Type: "int", Value: strconv.FormatInt(rv.Int(), 10)}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return cursorValue{Type: "uint", Value: strconv.FormatUint(rv.Uint(), 10)}, nil
+	case reflect.Float32, reflect.Float64:
+		return cursorValue{Type: "float", Value: strconv.FormatFloat(rv.Float(), 'g', -1, 64)}, nil
+	case reflect.String:
+		return cursorValue{Type: "string", Value: rv.String()}, nil
+	case reflect.Bool:
+		return cursorValue{Type: "bool", Value: strconv.FormatBool(rv.Bool())}, nil
+	default:
+		return cursorValue{}, fmt.Errorf("unsupported cursor value of type %T", value)
+	}
+}
+
+func (cv cursorValue) decode() (any, error) {
+	switch cv.Type {
+	case "time":
+		return time.Parse(time.RFC3339Nano, cv.Value)
+	case "bytes":
+		return base64.StdEncoding.DecodeString(cv.Value)
+	case "int":
+		return strconv.ParseInt(cv.Value, 10, 64)
+	case "uint":
+		return strconv.ParseUint(cv.Value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(cv.Value, 64)
+	case "string":
+		return cv.Value, nil
+	case "bool":
+		return strconv.ParseBool(cv.Value)
+	default:
+		return nil, fmt.Errorf("unknown value type %q", cv.Type)
+	}
+}
@@ -0,0 +1,174 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type paginatedItem struct {
+	ID    int64
+	Name  string
+	Score int
+}
+
+func setupPaginateTest(t *testing.T) *Db {
+	t.Helper()
+
+	d := getTestDb(t, DbConnectOptions{})
+	if _, err := d.Exec("CREATE TABLE scores (id INTEGER PRIMARY KEY, name TEXT NOT NULL, score INTEGER NOT NULL)"); err != nil {
+		t.Fatalf("Creating table failed: %v", err)
+	}
+
+	for i := 1; i <= 7; i++ {
+		if _, err := d.Exec("INSERT INTO scores (id, name, score) VALUES (?, ?, ?)", i, fmt.Sprintf("player%d", i), i%3); err != nil {
+			t.Fatalf("Inserting row failed: %v", err)
+		}
+	}
+
+	return d
+}
+
+func TestPaginate(t *testing.T) {
+	d := setupPaginateTest(t)
+	ctx := context.Background()
+	orderBy := []SortColumn{{Column: "id"}}
+
+	page, err := Paginate[paginatedItem](ctx, d, "SELECT * FROM scores WHERE score >= ?", orderBy, 2, 2, 0)
+	if err != nil {
+		t.Fatalf("Paginate failed: %v", err)
+	}
+
+	if page.Total != 7 || page.TotalPages != 4 {
+		t.Errorf("Expected 7 rows on 4 pages, got %d on %d", page.Total, page.TotalPages)
+	}
+	if len(page.Items) != 2 || page.Items[0].ID != 3 || page.Items[1].ID != 4 {
+		t.Errorf("Expected ids 3 and 4, got %+v", page.Items)
+	}
+
+	page, err = Paginate[paginatedItem](ctx, d, "SELECT * FROM scores", orderBy, 9, 2)
+	if err != nil {
+		t.Fatalf("Paginate past the end failed: %v", err)
+	}
+	if page.Items == nil || len(page.Items) != 0 {
+		t.Errorf("Expected an empty page, got %+v", page.Items)
+	}
+
+	if _, err := Paginate[paginatedItem](ctx, d, "SELECT * FROM scores", orderBy, 1, 0); err == nil {
+		t.Error("Expected an error for a page size of 0")
+	}
+}
+
+func TestPaginateKeyset(t *testing.T) {
+	d := setupPaginateTest(t)
+	ctx := context.Background()
+	orderBy := []SortColumn{{Column: "score", Desc: true}, {Column: "id"}}
+
+	var ids []int64
+	cursor := ""
+	for pages := 0; ; pages++ {
+		if pages > 4 {
+			t.Fatal("Expected the last page to have no cursor")
+		}
+
+		page, err := PaginateKeyset[paginatedItem](ctx, d, "SELECT * FROM scores", orderBy, cursor, 3)
+		if err != nil {
+			t.Fatalf("PaginateKeyset failed: %v", err)
+		}
+		for _, item := range page.Items {
+			ids = append(ids, item.ID)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	expected := []int64{2, 5, 1, 4, 7, 3, 6}
+	if fmt.Sprint(ids) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, ids)
+	}
+
+	_, err := PaginateKeyset[paginatedItem](ctx, d, "SELECT * FROM scores", orderBy, "not a cursor", 3)
+	if !errors.Is(err, ErrInvalidCursor) {
+		t.Errorf("Expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestPaginatedQuery(t *testing.T) {
+	orderBy := []SortColumn{{Column: "name"}, {Column: "id", Desc: true}}
+
+	tests := []struct {
+		dbType   string
+		expected string
+	}{
+		{"mysql", "SELECT * FROM (SELECT * FROM users) `paginated` ORDER BY `name`, `id` DESC LIMIT 10 OFFSET 20"},
+		{"postgres", `SELECT * FROM (SELECT * FROM users) "paginated" ORDER BY "name", "id" DESC LIMIT 10 OFFSET 20`},
+		{"sqlite", `SELECT * FROM (SELECT * FROM users) "paginated" ORDER BY "name", "id" DESC LIMIT 10 OFFSET 20`},
+		{"mssql", "SELECT * FROM (SELECT * FROM users) [paginated] ORDER BY [name], [id] DESC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY"},
+	}
+
+	for _, tt := range tests {
+		dialect, err := GetDialect(tt.dbType)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		query, err := paginatedQuery(dialect, "SELECT * FROM users", "", orderBy, 10, 20)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.dbType, err)
+		}
+		if query != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.dbType, tt.expected, query)
+		}
+	}
+
+	dialect, _ := GetDialect("mssql")
+	query, _ := paginatedQuery(dialect, "SELECT * FROM users", "", nil, 10, 0)
+	expected := "SELECT * FROM (SELECT * FROM users) [paginated] ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY"
+	if query != expected {
+		t.Errorf("Expected %q, got %q", expected, query)
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	dialect, _ := GetDialect("postgres")
+	orderBy := []SortColumn{{Column: "score", Desc: true}, {Column: "id"}}
+
+	condition, args := keysetCondition(dialect, orderBy, []any{int64(2), int64(5)})
+
+	expected := `(("score" < ?) OR ("score" = ? AND "id" > ?))`
+	if condition != expected {
+		t.Errorf("Expected %q, got %q", expected, condition)
+	}
+	if fmt.Sprint(args) != "[2 2 5]" {
+		t.Errorf("Expected args [2 2 5], got %v", args)
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 30, 0, 123456000, time.UTC)
+	name := "x"
+
+	cursor, err := EncodeCursor(int32(-4), uint8(7), 1.5, "a/b", true, []byte{1, 2}, created, &name)
+	if err != nil {
+		t.Fatalf("EncodeCursor failed: %v", err)
+	}
+
+	values, err := DecodeCursor(cursor)
+	if err != nil {
+		t.Fatalf("DecodeCursor failed: %v", err)
+	}
+
+	expected := []any{int64(-4), uint64(7), 1.5, "a/b", true, []byte{1, 2}, created, "x"}
+	if fmt.Sprintf("%#v", values) != fmt.Sprintf("%#v", expected) {
+		t.Errorf("Expected %#v, got %#v", expected, values)
+	}
+
+	if _, err := EncodeCursor(nil); err == nil {
+		t.Error("Expected an error for a NULL value")
+	}
+}
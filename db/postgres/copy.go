@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"io"
+	"strings"
+)
+
+// CopyFrom inserts the rows returned by next with COPY FROM STDIN. next returns io.EOF after the last row.
+// table may be qualified with its schema like public.users
+func (mdb *PgSqlDb) CopyFrom(ctx context.Context, table string, columns []string, next func() ([]any, error)) (int64, error) {
+	conn, err := mdb.DbObj.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	var copied int64
+	err = conn.Raw(func(driverConn any) error {
+		stdlibConn, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("COPY requires a pgx connection, got %T", driverConn)
+		}
+
+		var copyErr error
+		copied, copyErr = stdlibConn.Conn().CopyFrom(ctx, pgx.Identifier(strings.Split(table, ".")), columns, &copySource{next: next})
+		return copyErr
+	})
+
+	return copied, err
+}
+
+// copySource adapts an iterator function to pgx.CopyFromSource
+type copySource struct {
+	next   func() ([]any, error)
+	values []any
+	err    error
+}
+
+func (s *copySource) Next() bool {
+	s.values, s.err = s.next()
+	if s.err == io.EOF {
+		s.err = nil
+		return false
+	}
+
+	return s.err == nil
+}
+
+func (s *copySource) Values() ([]any, error) {
+	return s.values, nil
+}
+
+func (s *copySource) Err() error {
+	return s.err
+}
@@ -2,8 +2,11 @@ package postgres
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
-	"github.com/MathiasMantai/gotools/cli"
+	"github.com/MathiasMantai/gotools/db/codegen"
+	"github.com/MathiasMantai/gotools/db/dblog"
+	"github.com/MathiasMantai/gotools/db/util"
 	"path/filepath"
 	"strings"
 )
@@ -11,6 +14,16 @@ import (
 type MigrationRunner struct {
 	Migrations []Migration
 	Db         *PgSqlDb
+
+	// defaults to the logger of Db
+	Logger dblog.Logger
+}
+
+func (mr *MigrationRunner) log() dblog.Logger {
+	if mr.Db == nil {
+		return dblog.Or(mr.Logger)
+	}
+	return dblog.Or(mr.Logger, mr.Db.Logger)
 }
 
 func CreateMigrationRunner(db *PgSqlDb) MigrationRunner {
@@ -37,53 +50,78 @@ func (mr *MigrationRunner) Run() error {
 	}
 
 	for i, migration := range mr.Migrations {
-		migrationText := fmt.Sprintf("migration %d - %s", i, migration.TableName)
-		cli.PrintWithTimeAndColor("=> attempting to apply "+migrationText, "blue", true)
+		mr.log().Debug("attempting to apply migration", "migration", i, "table", migration.TableName)
 
 		applied, err := mr.IsMigrationApplied(ctx, migration.TableName)
 		if err != nil {
-			cli.PrintWithTimeAndColor(fmt.Sprintf("x> error checking whether migration '%s' is applied: %v", migration.TableName, err), "red", true)
+			mr.log().Error("checking whether migration is applied failed", "migration", i, "table", migration.TableName, "error", err)
 			return fmt.Errorf("checking migration '%s' failed: %w", migration.TableName, err)
 		}
 
 		if !applied {
-			query := migration.CreateQuery()
-
-			_, err := mr.Db.DbObj.Exec(query)
-			if err != nil {
-				cli.PrintWithTimeAndColor(fmt.Sprintf("x> error executing %s: %v", migrationText, err), "red", true)
-				return fmt.Errorf("executing migration '%s' failed: %w", migration.TableName, err)
+			if migration.autoIncrementFields() > 1 {
+				mr.log().Warn("multiple auto increment fields, only the first one becomes SERIAL PRIMARY KEY", "table", migration.TableName)
 			}
 
-			cli.PrintWithTimeAndColor("=> "+migrationText+" successfully applied", "green", true)
-			err = mr.LogMigration(ctx, migration.TableName, migration.Description)
+			err := util.WithTx(ctx, mr.Db.DbObj, func(tx *sql.Tx) error {
+				if _, err := tx.ExecContext(ctx, migration.CreateQuery()); err != nil {
+					mr.log().Error("executing migration failed", "migration", i, "table", migration.TableName, "error", err)
+					return fmt.Errorf("executing migration '%s' failed: %w", migration.TableName, err)
+				}
+
+				for j, fkQuery := range migration.CreateForeignKeyQueries() {
+					if _, err := tx.ExecContext(ctx, fkQuery); err != nil {
+						mr.log().Error("executing foreign key failed", "table", migration.TableName, "foreign_key", j+1, "error", err)
+						return fmt.Errorf("adding foreign key for migration '%s' failed: %w", migration.TableName, err)
+					}
+				}
+
+				if err := mr.LogMigrationTx(ctx, tx, migration.TableName, migration.Description); err != nil {
+					mr.log().Error("logging migration failed", "migration", i, "table", migration.TableName, "error", err)
+					return fmt.Errorf("logging migration '%s' failed: %w", migration.TableName, err)
+				}
+
+				return nil
+			})
 			if err != nil {
-				cli.PrintWithTimeAndColor(fmt.Sprintf("x> error logging %s: %v", migrationText, err), "red", true)
-				return fmt.Errorf("logging migration '%s' failed: %w", migration.TableName, err)
+				return err
 			}
+
+			mr.log().Info("migration applied", "migration", i, "table", migration.TableName)
 		} else {
-			cli.PrintWithTimeAndColor("=> "+migrationText+" already applied. Skipping...", "yellow", true)
+			mr.log().Debug("migration already applied", "migration", i, "table", migration.TableName)
 		}
 	}
 
-	cli.PrintWithTimeAndColor("=> All migrations processed.", "green", true)
+	mr.log().Debug("all migrations processed")
 	return nil
 }
 
-func (mr *MigrationRunner) LogMigration(ctx context.Context, tableName string, description string) error {
-	cli.PrintWithTimeAndColor("=> logging migration '"+tableName+"'...", "blue", true)
-
-	insertQuery := `
+const migrationLogQuery = `
         INSERT INTO migrations (name, description, applied_at)
         VALUES ($1, $2, NOW())
     `
 
-	_, err := mr.Db.DbObj.Exec(insertQuery, tableName, description)
+func (mr *MigrationRunner) LogMigration(ctx context.Context, tableName string, description string) error {
+	mr.log().Debug("logging migration", "table", tableName)
+
+	_, err := mr.Db.DbObj.ExecContext(ctx, migrationLogQuery, tableName, description)
+	if err != nil {
+		return fmt.Errorf("inserting migration log for '%s' failed: %w", tableName, err)
+	}
+
+	return nil
+}
+
+// LogMigrationTx logs a migration inside tx so it is only recorded if the migration is committed
+func (mr *MigrationRunner) LogMigrationTx(ctx context.Context, tx *sql.Tx, tableName string, description string) error {
+	mr.log().Debug("logging migration", "table", tableName)
+
+	_, err := tx.ExecContext(ctx, migrationLogQuery, tableName, description)
 	if err != nil {
 		return fmt.Errorf("inserting migration log for '%s' failed: %w", tableName, err)
 	}
 
-	cli.PrintWithTimeAndColor("=> logged migration '"+tableName+"' successfully", "green", true)
 	return nil
 }
 
@@ -115,36 +153,41 @@ func (mr *MigrationRunner) SetupMigrationTable(ctx context.Context) error {
 		applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW() -- Zeitstempel der Anwendung
 	);
 	`
-	cli.PrintWithTimeAndColor("=> ensuring migrations table exists...", "blue", true)
+	mr.log().Debug("ensuring migrations table exists")
 	_, err := mr.Db.DbObj.Exec(query)
 	if err != nil {
-		cli.PrintWithTimeAndColor(fmt.Sprintf("x> error creating/checking migrations table: %v", err), "red", true)
+		mr.log().Error("creating migrations table failed", "error", err)
 		return fmt.Errorf("creating/checking migrations table failed: %w", err)
 	}
-	cli.PrintWithTimeAndColor("=> migrations table check complete.", "green", true)
 	return nil
 }
 
+// ConvertToStruct returns a gofmt'd Go file with a struct for the migration at index.
+// the package is named after targetDir and fields of nullable columns are pointers
 func (mr *MigrationRunner) ConvertToStruct(targetDir string, index int, jsonMapping bool) string {
 	if index < 0 || index >= len(mr.Migrations) {
 		return "// Error: Invalid migration index"
 	}
 	targetMigration := mr.Migrations[index]
 
-	fields := ""
-	structName := ToGoStructName(targetMigration.TableName)
+	table := codegen.Table{Name: targetMigration.TableName}
+	for i, field := range targetMigration.Fields {
+		table.Columns = append(table.Columns, codegen.Column{
+			Name:     field.Name,
+			GoType:   MapPgTypeToGo(field.DataType),
+			Nullable: targetMigration.columnNullable(i),
+		})
+	}
 
-	for _, field := range targetMigration.Fields {
-		goType := MapPgTypeToGo(field.DataType)
-		goName := ToGoFieldName(field.Name)
-		jsonTag := ""
-		if jsonMapping {
-			jsonTag = fmt.Sprintf("`json:\"%s\"`", field.Name)
-		}
-		fields += fmt.Sprintf("\t%s %s %s\n", goName, goType, jsonTag)
+	code, err := codegen.Generate([]codegen.Table{table}, codegen.Options{
+		Package:  codegen.PackageName(filepath.Base(targetDir)),
+		JSONTags: jsonMapping,
+	})
+	if err != nil {
+		return "// Error: " + err.Error()
 	}
 
-	return fmt.Sprintf("package %s // Oder ein passender Paketname\n\nimport \"time\" // Beispielimport\n\ntype %s struct {\n%s}", filepath.Base(targetDir), structName, fields)
+	return string(code)
 }
 
 func ToGoStructName(dbName string) string {
@@ -161,32 +204,42 @@ func ToGoFieldName(dbName string) string {
 	return ToGoStructName(dbName)
 }
 
+// MapPgTypeToGo returns the Go type a column of pgType is scanned into
 func MapPgTypeToGo(pgType string) string {
-	pgType = strings.ToLower(pgType)
+	pgType = strings.ToLower(strings.TrimSpace(pgType))
 
 	switch {
-	case strings.HasPrefix(pgType, "int"), strings.HasPrefix(pgType, "serial"), strings.HasPrefix(pgType, "smallint"):
-		return "int"
-	case strings.HasPrefix(pgType, "bigint"), strings.HasPrefix(pgType, "bigserial"):
+	case strings.HasSuffix(pgType, "[]"), strings.HasPrefix(pgType, "array"):
+		return "any"
+	case strings.HasPrefix(pgType, "interval"):
+		return "string"
+	case strings.HasPrefix(pgType, "smallint"), strings.HasPrefix(pgType, "int2"), strings.HasPrefix(pgType, "smallserial"):
+		return "int16"
+	case strings.HasPrefix(pgType, "bigint"), strings.HasPrefix(pgType, "int8"), strings.HasPrefix(pgType, "bigserial"):
 		return "int64"
+	case strings.HasPrefix(pgType, "int"), strings.HasPrefix(pgType, "serial"):
+		return "int"
 	case strings.HasPrefix(pgType, "numeric"), strings.HasPrefix(pgType, "decimal"):
 		return "float64"
-	case strings.HasPrefix(pgType, "real"), strings.HasPrefix(pgType, "double precision"):
+	case strings.HasPrefix(pgType, "real"), strings.HasPrefix(pgType, "float4"):
+		return "float32"
+	case strings.HasPrefix(pgType, "double precision"), strings.HasPrefix(pgType, "float"):
 		return "float64"
-	case strings.HasPrefix(pgType, "text"), strings.HasPrefix(pgType, "varchar"), strings.HasPrefix(pgType, "char"), strings.HasPrefix(pgType, "uuid"):
+	case strings.HasPrefix(pgType, "text"), strings.HasPrefix(pgType, "varchar"), strings.HasPrefix(pgType, "char"),
+		strings.HasPrefix(pgType, "uuid"), strings.HasPrefix(pgType, "citext"), strings.HasPrefix(pgType, "inet"):
 		return "string"
-	case strings.HasPrefix(pgType, "timestamp"):
-		return "time.Time"
-	case strings.HasPrefix(pgType, "date"):
+	case strings.HasPrefix(pgType, "timestamp"), strings.HasPrefix(pgType, "date"):
 		return "time.Time"
+	case strings.HasPrefix(pgType, "time"):
+		return "string"
 	case strings.HasPrefix(pgType, "bool"):
 		return "bool"
 	case strings.HasPrefix(pgType, "bytea"):
 		return "[]byte"
-	case strings.HasPrefix(pgType, "json"), strings.HasPrefix(pgType, "jsonb"):
-		return "[]byte"
+	case strings.HasPrefix(pgType, "json"):
+		return "json.RawMessage"
 	default:
-		return "interface{}"
+		return "any"
 	}
 }
 
@@ -194,10 +247,49 @@ type Migration struct {
 	TableName   string
 	Description string
 	Fields      []MigrationField
+	ForeignKeys []ForeignKey
+
+	// NotNullByDefault creates fields without Nullable as NOT NULL like the other dialects do.
+	// without it every column is nullable as in earlier versions
+	NotNullByDefault bool
+}
+
+// autoIncrementFields counts the fields with AutoIncrement set
+func (m *Migration) autoIncrementFields() int {
+	count := 0
+	for _, field := range m.Fields {
+		if field.AutoIncrement {
+			count++
+		}
+	}
+	return count
+}
+
+// columnNullable reports if CreateQuery creates the column of the field at index i without NOT NULL.
+// primary keys and serial columns are NOT NULL anyway
+func (m *Migration) columnNullable(i int) bool {
+	field := m.Fields[i]
+
+	dataType := strings.ToLower(field.DataType)
+	if strings.HasSuffix(dataType, "serial") || field.PrimaryKey && m.autoIncrementFields() == 0 {
+		return false
+	}
+	if field.AutoIncrement {
+		for _, previous := range m.Fields[:i] {
+			if previous.AutoIncrement {
+				return !m.NotNullByDefault || field.Nullable
+			}
+		}
+		// the first auto increment field becomes SERIAL PRIMARY KEY
+		return false
+	}
+
+	return !m.NotNullByDefault || field.Nullable
 }
 
 func (m *Migration) CreateQuery() string {
 	var fieldDefs []string
+	var primaryKeyFields []string
 	primaryKeyDefined := false
 
 	for _, field := range m.Fields {
@@ -206,12 +298,23 @@ func (m *Migration) CreateQuery() string {
 		if field.AutoIncrement && !primaryKeyDefined {
 			fieldDef = fmt.Sprintf("%q SERIAL PRIMARY KEY", field.Name)
 			primaryKeyDefined = true
-		} else if field.AutoIncrement {
-			fmt.Printf("WARNUNG: Mehrere AutoIncrement-Felder in Tabelle '%s' definiert. Nur das erste wird als SERIAL PRIMARY KEY behandelt.\n", m.TableName)
+		} else {
+			// further auto increment fields are plain columns, the migration runner warns about them
+			if m.NotNullByDefault && !field.Nullable {
+				fieldDef += " NOT NULL"
+			}
+
+			if field.PrimaryKey {
+				primaryKeyFields = append(primaryKeyFields, fmt.Sprintf("%q", field.Name))
+			}
 		}
 		fieldDefs = append(fieldDefs, fieldDef)
 	}
 
+	if len(primaryKeyFields) > 0 && !primaryKeyDefined {
+		fieldDefs = append(fieldDefs, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKeyFields, ", ")))
+	}
+
 	fieldsSQL := strings.Join(fieldDefs, ",\n\t")
 
 	query := fmt.Sprintf(`
@@ -223,10 +326,29 @@ func (m *Migration) CreateQuery() string {
 	return strings.TrimSpace(query)
 }
 
+func (m *Migration) CreateForeignKeyQueries() []string {
+	var queries []string
+
+	for _, fk := range m.ForeignKeys {
+		query := fmt.Sprintf(`ALTER TABLE %q ADD CONSTRAINT %q FOREIGN KEY (%q) REFERENCES %q (%q)`,
+			m.TableName,
+			fk.Name,
+			fk.Column,
+			fk.ReferenceTable,
+			fk.ReferenceColumn,
+		)
+		queries = append(queries, query)
+	}
+
+	return queries
+}
+
+// AddField adds a nullable field. its Nullable flag only matters with NotNullByDefault
 func (m *Migration) AddField(name string, dataType string, autoIncrement bool) {
 	m.Fields = append(m.Fields, MigrationField{
 		Name:          name,
 		DataType:      dataType,
+		Nullable:      true,
 		AutoIncrement: autoIncrement,
 	})
 }
@@ -234,5 +356,14 @@ func (m *Migration) AddField(name string, dataType string, autoIncrement bool) {
 type MigrationField struct {
 	Name          string
 	DataType      string
+	Nullable      bool
+	PrimaryKey    bool
 	AutoIncrement bool
 }
+
+type ForeignKey struct {
+	Name            string
+	Column          string
+	ReferenceTable  string
+	ReferenceColumn string
+}
@@ -0,0 +1,267 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// policies for choosing a replica
+const (
+	ReplicaRoundRobin       = "round-robin"
+	ReplicaLeastConnections = "least-connections"
+)
+
+type primaryKey struct{}
+
+// Primary marks ctx so that queries executed with it are sent to the primary instead of a replica.
+// use it to read data that was just written
+func Primary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryKey{}, true)
+}
+
+func isPrimary(ctx context.Context) bool {
+	usePrimary, _ := ctx.Value(primaryKey{}).(bool)
+	return usePrimary
+}
+
+// a broken replica gets queries again after replicaRetryBackoff, doubled after every
+// further failure up to replicaMaxRetryBackoff
+var (
+	replicaRetryBackoff    = 5 * time.Second
+	replicaMaxRetryBackoff = 5 * time.Minute
+)
+
+type replica struct {
+	db      GotoolsDb
+	healthy atomic.Bool
+
+	// consecutive failures and the unix nano time at which a broken replica is tried again
+	failures atomic.Int32
+	retryAt  atomic.Int64
+}
+
+// replicaSet holds the read replicas of a Db
+type replicaSet struct {
+	mu       sync.RWMutex
+	replicas []*replica
+	policy   string
+	next     atomic.Uint64
+}
+
+// AddReplica adds an already connected read replica. Query and QueryRow are sent to a healthy
+// replica while Exec and transactions always use DbObj
+func (mdb *Db) AddReplica(replicaDb GotoolsDb) {
+	if mdb.replicas == nil {
+		mdb.replicas = &replicaSet{policy: ReplicaRoundRobin}
+	}
+
+	r := &replica{db: replicaDb}
+	r.healthy.Store(true)
+
+	mdb.replicas.mu.Lock()
+	mdb.replicas.replicas = append(mdb.replicas.replicas, r)
+	mdb.replicas.mu.Unlock()
+}
+
+// ConnectReplicas connects one read replica per options using the database type of the primary
+func (mdb *Db) ConnectReplicas(ctx context.Context, options ...DbConnectOptions) error {
+	if mdb.DbObj == nil {
+		return errors.New("the primary has to be connected before its replicas")
+	}
+
+	for i, replicaOptions := range options {
+		var replicaDb Db
+		if err := replicaDb.ConnectContext(ctx, mdb.DbType, replicaOptions); err != nil {
+			return fmt.Errorf("connecting replica %d failed: %w", i+1, err)
+		}
+		mdb.AddReplica(replicaDb.DbObj)
+	}
+
+	return nil
+}
+
+// SetReplicaPolicy chooses how a replica is picked. ReplicaRoundRobin is the default
+func (mdb *Db) SetReplicaPolicy(policy string) error {
+	if policy != ReplicaRoundRobin && policy != ReplicaLeastConnections {
+		return fmt.Errorf("unsupported replica policy %q", policy)
+	}
+
+	if mdb.replicas == nil {
+		mdb.replicas = &replicaSet{}
+	}
+
+	mdb.replicas.mu.Lock()
+	mdb.replicas.policy = policy
+	mdb.replicas.mu.Unlock()
+
+	return nil
+}
+
+// Replicas returns the connected read replicas
+func (mdb *Db) Replicas() []GotoolsDb {
+	if mdb.replicas == nil {
+		return nil
+	}
+
+	mdb.replicas.mu.RLock()
+	defer mdb.replicas.mu.RUnlock()
+
+	replicas := make([]GotoolsDb, len(mdb.replicas.replicas))
+	for i, r := range mdb.replicas.replicas {
+		replicas[i] = r.db
+	}
+
+	return replicas
+}
+
+// CheckReplicas pings every replica and updates its health. unhealthy replicas receive no queries
+// until a later check succeeds or their retry backoff passed. the number of healthy replicas is returned
+func (mdb *Db) CheckReplicas(ctx context.Context) int {
+	if mdb.replicas == nil {
+		return 0
+	}
+
+	mdb.replicas.mu.RLock()
+	replicas := mdb.replicas.replicas
+	mdb.replicas.mu.RUnlock()
+
+	healthy := 0
+	for _, r := range replicas {
+		if err := r.db.PingContext(ctx); err != nil {
+			r.markBroken()
+			continue
+		}
+		r.markHealthy()
+		healthy++
+	}
+
+	return healthy
+}
+
+// StartReplicaHealthChecks runs CheckReplicas every interval until the returned function is called
+func (mdb *Db) StartReplicaHealthChecks(interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				checkCtx, checkCancel := context.WithTimeout(ctx, interval)
+				mdb.CheckReplicas(checkCtx)
+				checkCancel()
+			}
+		}
+	}()
+
+	return cancel
+}
+
+// reader returns the connection a read query is sent to
+func (mdb *Db) reader(ctx context.Context) (GotoolsDb, *replica) {
+	if mdb.replicas == nil || isPrimary(ctx) {
+		return mdb.DbObj, nil
+	}
+
+	r := mdb.replicas.pick()
+	if r == nil {
+		return mdb.DbObj, nil
+	}
+
+	return r.db, r
+}
+
+// pick chooses a healthy replica according to the policy or returns nil if there is none
+func (s *replicaSet) pick() *replica {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now().UnixNano()
+	var healthy []*replica
+	for _, r := range s.replicas {
+		if r.healthy.Load() || r.retryDue(now) {
+			healthy = append(healthy, r)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	if s.policy == ReplicaLeastConnections {
+		best := healthy[0]
+		bestInUse := best.db.DB().Stats().InUse
+		for _, r := range healthy[1:] {
+			if inUse := r.db.DB().Stats().InUse; inUse < bestInUse {
+				best, bestInUse = r, inUse
+			}
+		}
+		return best
+	}
+
+	n := s.next.Add(1) - 1
+	return healthy[n%uint64(len(healthy))]
+}
+
+// report takes a replica out of rotation after a connection error and resets its backoff after a success.
+// it returns true if the replica was taken out so the query can be retried on the primary
+func (r *replica) report(err error) bool {
+	if r == nil {
+		return false
+	}
+
+	if isReplicaFailure(err) {
+		r.markBroken()
+		return true
+	}
+	if err == nil && r.failures.Load() > 0 {
+		r.markHealthy()
+	}
+
+	return false
+}
+
+// isReplicaFailure reports if err means that the replica cannot be reached. database/sql retries
+// driver.ErrBadConn itself, so a dead replica shows up as a network or driver error or as a closed *sql.DB
+func isReplicaFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return Classify(err) == ConnectionLost || err.Error() == "sql: database is closed"
+}
+
+// markBroken takes the replica out of rotation until its backoff passed
+func (r *replica) markBroken() {
+	failures := r.failures.Add(1)
+	backoff := replicaRetryBackoff << min(failures-1, 16)
+	backoff = min(backoff, replicaMaxRetryBackoff)
+
+	r.healthy.Store(false)
+	r.retryAt.Store(time.Now().Add(backoff).UnixNano())
+}
+
+func (r *replica) markHealthy() {
+	r.failures.Store(0)
+	r.retryAt.Store(0)
+	r.healthy.Store(true)
+}
+
+// retryDue puts a broken replica back into rotation once its backoff passed. if it
+// is still broken the next connection error takes it out again with a longer backoff
+func (r *replica) retryDue(now int64) bool {
+	retryAt := r.retryAt.Load()
+	if retryAt == 0 || now < retryAt || !r.retryAt.CompareAndSwap(retryAt, 0) {
+		return false
+	}
+
+	r.healthy.Store(true)
+	return true
+}
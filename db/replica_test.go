@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// setupReplicaTest creates a primary and two replicas, each with a table naming the database
+func setupReplicaTest(t *testing.T) *Db {
+	t.Helper()
+
+	primary := getTestDb(t, DbConnectOptions{})
+	for i, name := range []string{"primary", "replica1", "replica2"} {
+		target := primary
+		if i > 0 {
+			target = getTestDb(t, DbConnectOptions{})
+		}
+
+		if _, err := target.Exec("CREATE TABLE origin (name TEXT)"); err != nil {
+			t.Fatalf("Creating table failed: %v", err)
+		}
+		if _, err := target.Exec("INSERT INTO origin (name) VALUES (?)", name); err != nil {
+			t.Fatalf("Inserting origin failed: %v", err)
+		}
+
+		if i > 0 {
+			primary.AddReplica(target.DbObj)
+		}
+	}
+
+	return primary
+}
+
+func readOrigin(t *testing.T, ctx context.Context, d *Db) string {
+	t.Helper()
+
+	var name string
+	if err := d.QueryRowContext(ctx, "SELECT name FROM origin").Scan(&name); err != nil {
+		t.Fatalf("Reading origin failed: %v", err)
+	}
+
+	return name
+}
+
+func TestReplicaRoundRobin(t *testing.T) {
+	d := setupReplicaTest(t)
+	ctx := context.Background()
+
+	first := readOrigin(t, ctx, d)
+	second := readOrigin(t, ctx, d)
+	third := readOrigin(t, ctx, d)
+
+	if first == second || first != third || first == "primary" || second == "primary" {
+		t.Errorf("Expected queries to alternate between replicas, got %s %s %s", first, second, third)
+	}
+}
+
+func TestReplicaPrimaryOverride(t *testing.T) {
+	d := setupReplicaTest(t)
+
+	if origin := readOrigin(t, Primary(context.Background()), d); origin != "primary" {
+		t.Errorf("Expected query on primary, got %s", origin)
+	}
+
+	var name string
+	err := d.WithTx(context.Background(), nil, func(tx DBOrTx) error {
+		return tx.QueryRow("SELECT name FROM origin").Scan(&name)
+	})
+	if err != nil {
+		t.Fatalf("WithTx failed: %v", err)
+	}
+
+	if name != "primary" {
+		t.Errorf("Expected transaction on primary, got %s", name)
+	}
+}
+
+func TestReplicaUnhealthyFallback(t *testing.T) {
+	d := setupReplicaTest(t)
+	ctx := context.Background()
+
+	for _, replicaDb := range d.Replicas() {
+		replicaDb.DB().Close()
+	}
+
+	if healthy := d.CheckReplicas(ctx); healthy != 0 {
+		t.Errorf("Expected no healthy replicas, got %d", healthy)
+	}
+
+	if origin := readOrigin(t, ctx, d); origin != "primary" {
+		t.Errorf("Expected fallback to primary, got %s", origin)
+	}
+}
+
+func TestReplicaLeastConnections(t *testing.T) {
+	d := setupReplicaTest(t)
+	ctx := context.Background()
+
+	if err := d.SetReplicaPolicy(ReplicaLeastConnections); err != nil {
+		t.Fatalf("SetReplicaPolicy failed: %v", err)
+	}
+
+	// keep a connection of the first replica busy
+	rows, err := d.Replicas()[0].QueryContext(ctx, "SELECT name FROM origin")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer rows.Close()
+
+	if origin := readOrigin(t, ctx, d); origin != "replica2" {
+		t.Errorf("Expected the idle replica2, got %s", origin)
+	}
+
+	if err := d.SetReplicaPolicy("random"); err == nil {
+		t.Error("Expected an error for an unsupported policy")
+	}
+}
+
+func TestReplicaRetryAfterBackoff(t *testing.T) {
+	d := setupReplicaTest(t)
+	ctx := context.Background()
+
+	backoff := replicaRetryBackoff
+	replicaRetryBackoff = 50 * time.Millisecond
+	t.Cleanup(func() { replicaRetryBackoff = backoff })
+
+	// a closed replica fails like an unreachable one. the read is retried on the primary
+	broken := d.replicas.replicas[0]
+	broken.db.DB().Close()
+
+	for i := 0; i < 4; i++ {
+		if origin := readOrigin(t, ctx, d); origin == "replica1" {
+			t.Fatalf("Expected no reads from the closed replica, got %s", origin)
+		}
+	}
+	if broken.healthy.Load() || broken.failures.Load() != 1 {
+		t.Fatalf("Expected the closed replica to be out of rotation after one failure")
+	}
+
+	// while it is broken every read goes to the other replica
+	for i := 0; i < 3; i++ {
+		if origin := readOrigin(t, ctx, d); origin != "replica2" {
+			t.Errorf("Expected reads on replica2 while replica1 is broken, got %s", origin)
+		}
+	}
+
+	// without health checks it is tried again after the backoff and fails with a doubled backoff
+	time.Sleep(60 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if origin := readOrigin(t, ctx, d); origin == "replica1" {
+			t.Fatalf("Expected no reads from the closed replica, got %s", origin)
+		}
+	}
+	if broken.failures.Load() != 2 {
+		t.Fatalf("Expected the closed replica to be retried after the backoff, got %d failures", broken.failures.Load())
+	}
+	if wait := time.Until(time.Unix(0, broken.retryAt.Load())); wait <= replicaRetryBackoff*3/2 {
+		t.Errorf("Expected the backoff to double after repeated failures, got %v", wait)
+	}
+
+	// a replica that works again is reset by its next successful query
+	working := d.replicas.replicas[1]
+	working.markBroken()
+	working.retryAt.Store(1)
+	if origin := readOrigin(t, ctx, d); origin != "replica2" {
+		t.Errorf("Expected replica2 after its backoff, got %s", origin)
+	}
+	if !working.healthy.Load() || working.failures.Load() != 0 {
+		t.Errorf("Expected a successful query to reset the backoff")
+	}
+
+	// without a reachable replica the reads go to the primary
+	working.db.DB().Close()
+	for i := 0; i < 2; i++ {
+		if origin := readOrigin(t, ctx, d); origin != "primary" {
+			t.Errorf("Expected reads on the primary without replicas, got %s", origin)
+		}
+	}
+}
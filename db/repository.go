@@ -0,0 +1,370 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// options of the db tag understood by Repository
+const (
+	// primary key. without it a column called id is used
+	TagPrimaryKey = "pk"
+	// set to the current time by Insert
+	TagCreated = "created"
+	// set to the current time by Insert and Update
+	TagUpdated = "updated"
+	// soft delete: Delete sets the current time and rows with a value are ignored
+	TagDeleted = "deleted"
+)
+
+// returningInserter is implemented by dialects which return generated columns from the INSERT statement itself.
+// values is the row list of a VALUES clause as created by valuesList
+type returningInserter interface {
+	InsertReturningQuery(table string, columns []string, values string, returning string) string
+}
+
+// Repository implements create, read, update and delete for the table of T.
+//
+// the table is set with a table tag on any field of T, usually a blank one, and otherwise derived
+// from the type name in snake case. columns are mapped like in Select and the db tag accepts the
+// options pk, created, updated and deleted:
+//
+//	type User struct {
+//		_         struct{}   `table:"users"`
+//		ID        int64      `db:"id,pk"`
+//		Name      string     `db:"name"`
+//		CreatedAt time.Time  `db:"created_at,created"`
+//		DeletedAt *time.Time `db:"deleted_at,deleted"`
+//	}
+//
+// an integer primary key with its zero value is generated by the database on Insert
+type Repository[T any] struct {
+	conn    DBOrTx
+	dialect Dialect
+	table   string
+	fields  []structField
+	columns []string
+	pk      structField
+	created *structField
+	updated *structField
+	deleted *structField
+}
+
+// NewRepository creates a repository for T on the connected database
+func NewRepository[T any](mdb *Db) (*Repository[T], error) {
+	dialect, err := mdb.Dialect()
+	if err != nil {
+		return nil, err
+	}
+
+	t := reflect.TypeFor[T]()
+	if !isStructMapping(t) {
+		return nil, fmt.Errorf("repository type %v has to be a struct", t)
+	}
+
+	r := &Repository[T]{
+		conn:    mdb,
+		dialect: dialect,
+		table:   tableName(t),
+	}
+
+	hasPk := false
+	seen := map[string]bool{}
+	for _, field := range structFields(t) {
+		if seen[field.Column] {
+			continue
+		}
+		seen[field.Column] = true
+		r.fields = append(r.fields, field)
+		r.columns = append(r.columns, field.Column)
+
+		switch {
+		case slices.Contains(field.Options, TagPrimaryKey):
+			if hasPk {
+				return nil, fmt.Errorf("repository type %v has more than one primary key", t)
+			}
+			r.pk, hasPk = field, true
+		case slices.Contains(field.Options, TagCreated):
+			r.created = &field
+		case slices.Contains(field.Options, TagUpdated):
+			r.updated = &field
+		case slices.Contains(field.Options, TagDeleted):
+			r.deleted = &field
+		}
+	}
+
+	for _, field := range []*structField{r.created, r.updated, r.deleted} {
+		if field == nil {
+			continue
+		}
+
+		fieldType := t.FieldByIndex(field.Index).Type
+		if fieldType != timeType && fieldType != reflect.TypeFor[*time.Time]() && fieldType != reflect.TypeFor[sql.NullTime]() {
+			return nil, fmt.Errorf("repository type %v: timestamp column %s has to be time.Time, *time.Time or sql.NullTime", t, field.Column)
+		}
+		if field == r.deleted && fieldType == timeType {
+			return nil, fmt.Errorf("repository type %v: deleted column %s has to be nullable", t, field.Column)
+		}
+	}
+
+	if !hasPk {
+		i := slices.IndexFunc(r.fields, func(field structField) bool { return field.Column == "id" })
+		if i < 0 {
+			return nil, fmt.Errorf("repository type %v has no field tagged with pk and no id column", t)
+		}
+		r.pk = r.fields[i]
+	}
+
+	return r, nil
+}
+
+// tableName reads the table tag of t or derives the name from the type
+func tableName(t reflect.Type) string {
+	for i := 0; i < t.NumField(); i++ {
+		if name := t.Field(i).Tag.Get("table"); name != "" {
+			return name
+		}
+	}
+
+	return ToSnakeCase(t.Name())
+}
+
+// WithTx returns a copy of the repository which runs its statements on tx,
+// for example inside of a WithTx callback
+func (r *Repository[T]) WithTx(tx DBOrTx) *Repository[T] {
+	copied := *r
+	copied.conn = tx
+	return &copied
+}
+
+// Table returns the name of the table
+func (r *Repository[T]) Table() string {
+	return r.table
+}
+
+// FindByID returns the row with the primary key id or sql.ErrNoRows
+func (r *Repository[T]) FindByID(ctx context.Context, id any) (T, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s = ?%s",
+		r.columnList(r.columns),
+		r.dialect.QuoteIdentifier(r.table),
+		r.dialect.QuoteIdentifier(r.pk.Column),
+		r.notDeleted(" AND "),
+	)
+
+	return Get[T](ctx, r.conn, Rebind(r.dialect.Placeholder(), query), id)
+}
+
+// FindWhere returns the rows matching where, a condition with ? placeholders like "name = ? AND age > ?".
+// an empty where returns all rows
+func (r *Repository[T]) FindWhere(ctx context.Context, where string, args ...any) ([]T, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s%s",
+		r.columnList(r.columns),
+		r.dialect.QuoteIdentifier(r.table),
+		r.whereClause(where),
+	)
+
+	return Select[T](ctx, r.conn, Rebind(r.dialect.Placeholder(), query), args...)
+}
+
+// Count returns the number of rows matching where. see FindWhere
+func (r *Repository[T]) Count(ctx context.Context, where string, args ...any) (int64, error) {
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s%s", r.dialect.QuoteIdentifier(r.table), r.whereClause(where))
+
+	var count int64
+	err := r.conn.QueryRowContext(ctx, Rebind(r.dialect.Placeholder(), query), args...).Scan(&count)
+	return count, err
+}
+
+// Insert inserts entity and sets its generated primary key and timestamps
+func (r *Repository[T]) Insert(ctx context.Context, entity *T) error {
+	v := reflect.ValueOf(entity).Elem()
+	now := repositoryNow()
+
+	for _, field := range []*structField{r.created, r.updated} {
+		if field != nil {
+			setTime(fieldByIndexAlloc(v, field.Index), now)
+		}
+	}
+
+	pkValue := fieldByIndexAlloc(v, r.pk.Index)
+	generatePk := (pkValue.CanInt() || pkValue.CanUint()) && pkValue.IsZero()
+
+	var columns []string
+	var args []any
+	for _, field := range r.fields {
+		if generatePk && field.Column == r.pk.Column {
+			continue
+		}
+		columns = append(columns, field.Column)
+		args = append(args, fieldValue(v, field.Index))
+	}
+
+	if len(columns) == 0 {
+		return fmt.Errorf("insert into %s: no columns besides the generated primary key", r.table)
+	}
+
+	values, args := valuesList(r.dialect.Placeholder(), [][]any{args})
+
+	if !generatePk {
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", r.dialect.QuoteIdentifier(r.table), r.columnList(columns), values)
+		_, err := r.conn.ExecContext(ctx, query, args...)
+		return err
+	}
+
+	if inserter, ok := r.dialect.(returningInserter); ok {
+		query := inserter.InsertReturningQuery(r.table, columns, values, r.pk.Column)
+		return r.conn.QueryRowContext(ctx, query, args...).Scan(pkValue.Addr().Interface())
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", r.dialect.QuoteIdentifier(r.table), r.columnList(columns), values)
+	result, err := r.conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("reading generated id of %s failed: %w", r.table, err)
+	}
+
+	if pkValue.CanInt() {
+		pkValue.SetInt(id)
+	} else {
+		pkValue.SetUint(uint64(id))
+	}
+
+	return nil
+}
+
+// Update writes all columns of entity except the primary key and the created and deleted columns.
+// sql.ErrNoRows is returned if no row with the primary key of entity exists. mysql only counts
+// changed rows unless clientFoundRows=true is set in the connection Params
+func (r *Repository[T]) Update(ctx context.Context, entity *T) error {
+	v := reflect.ValueOf(entity).Elem()
+
+	if r.updated != nil {
+		setTime(fieldByIndexAlloc(v, r.updated.Index), repositoryNow())
+	}
+
+	var assignments []string
+	var args []any
+	for _, field := range r.fields {
+		if field.Column == r.pk.Column || (r.created != nil && field.Column == r.created.Column) || (r.deleted != nil && field.Column == r.deleted.Column) {
+			continue
+		}
+		assignments = append(assignments, r.dialect.QuoteIdentifier(field.Column)+" = ?")
+		args = append(args, fieldValue(v, field.Index))
+	}
+
+	if len(assignments) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s WHERE %s = ?%s",
+		r.dialect.QuoteIdentifier(r.table),
+		strings.Join(assignments, ", "),
+		r.dialect.QuoteIdentifier(r.pk.Column),
+		r.notDeleted(" AND "),
+	)
+	args = append(args, fieldValue(v, r.pk.Index))
+
+	return r.execOne(ctx, query, args...)
+}
+
+// Delete removes the row with the primary key id or, with a deleted column, marks it as deleted.
+// sql.ErrNoRows is returned if no such row exists
+func (r *Repository[T]) Delete(ctx context.Context, id any) error {
+	if r.deleted == nil {
+		query := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", r.dialect.QuoteIdentifier(r.table), r.dialect.QuoteIdentifier(r.pk.Column))
+		return r.execOne(ctx, query, id)
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s = ?%s",
+		r.dialect.QuoteIdentifier(r.table),
+		r.dialect.QuoteIdentifier(r.deleted.Column),
+		r.dialect.QuoteIdentifier(r.pk.Column),
+		r.notDeleted(" AND "),
+	)
+
+	return r.execOne(ctx, query, repositoryNow(), id)
+}
+
+// execOne executes a statement that has to affect a row
+func (r *Repository[T]) execOne(ctx context.Context, query string, args ...any) error {
+	result, err := r.conn.ExecContext(ctx, Rebind(r.dialect.Placeholder(), query), args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (r *Repository[T]) whereClause(where string) string {
+	conditions := []string{}
+	if where != "" {
+		conditions = append(conditions, "("+where+")")
+	}
+	if r.deleted != nil {
+		conditions = append(conditions, r.notDeleted(""))
+	}
+
+	if len(conditions) == 0 {
+		return ""
+	}
+
+	return " WHERE " + strings.Join(conditions, " AND ")
+}
+
+// notDeleted returns the soft delete condition with prefix or an empty string without a deleted column
+func (r *Repository[T]) notDeleted(prefix string) string {
+	if r.deleted == nil {
+		return ""
+	}
+
+	return prefix + r.dialect.QuoteIdentifier(r.deleted.Column) + " IS NULL"
+}
+
+func (r *Repository[T]) columnList(columns []string) string {
+	return strings.Join(quoteIdentifiers(r.dialect, columns), ", ")
+}
+
+// fieldValue returns the value of a field or nil if it belongs to a nil embedded pointer
+func fieldValue(v reflect.Value, index []int) any {
+	field, err := v.FieldByIndexErr(index)
+	if err != nil {
+		return nil
+	}
+
+	return field.Interface()
+}
+
+// repositoryNow is truncated to microseconds, the precision of mysql and postgres
+func repositoryNow() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// setTime sets a time.Time, *time.Time or sql.NullTime field
+func setTime(field reflect.Value, now time.Time) {
+	switch field.Interface().(type) {
+	case time.Time:
+		field.Set(reflect.ValueOf(now))
+	case *time.Time:
+		field.Set(reflect.ValueOf(&now))
+	case sql.NullTime:
+		field.Set(reflect.ValueOf(sql.NullTime{Time: now, Valid: true}))
+	}
+}
@@ -0,0 +1,225 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/MathiasMantai/gotools/db/postgres"
+	"regexp"
+	"testing"
+	"time"
+)
+
+type repoUser struct {
+	_         struct{}   `table:"users"`
+	ID        int64      `db:"id,pk"`
+	Name      string     `db:"name"`
+	CreatedAt time.Time  `db:"created_at,created"`
+	UpdatedAt time.Time  `db:"updated_at,updated"`
+	DeletedAt *time.Time `db:"deleted_at,deleted"`
+}
+
+type repoTag struct {
+	Code  string `db:"id"`
+	Label string
+}
+
+func setupRepositoryTest(t *testing.T) (*Db, *Repository[repoUser]) {
+	t.Helper()
+
+	d := getTestDb(t, DbConnectOptions{})
+	statements := []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)",
+		"CREATE TABLE repo_tag (id TEXT PRIMARY KEY, label TEXT)",
+	}
+	for _, statement := range statements {
+		if _, err := d.Exec(statement); err != nil {
+			t.Fatalf("Creating table failed: %v", err)
+		}
+	}
+
+	repo, err := NewRepository[repoUser](d)
+	if err != nil {
+		t.Fatalf("NewRepository failed: %v", err)
+	}
+
+	return d, repo
+}
+
+func TestRepositoryCrud(t *testing.T) {
+	_, repo := setupRepositoryTest(t)
+	ctx := context.Background()
+
+	alice := repoUser{Name: "alice"}
+	if err := repo.Insert(ctx, &alice); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	bob := repoUser{Name: "bob"}
+	if err := repo.Insert(ctx, &bob); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	if alice.ID != 1 || bob.ID != 2 || alice.CreatedAt.IsZero() || alice.UpdatedAt.IsZero() {
+		t.Errorf("Expected generated ids and timestamps, got %+v %+v", alice, bob)
+	}
+
+	found, err := repo.FindByID(ctx, bob.ID)
+	if err != nil {
+		t.Fatalf("FindByID failed: %v", err)
+	}
+	if found.Name != "bob" || !found.CreatedAt.Equal(bob.CreatedAt) {
+		t.Errorf("Expected bob, got %+v", found)
+	}
+
+	bob.Name = "robert"
+	if err := repo.Update(ctx, &bob); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	users, err := repo.FindWhere(ctx, "name LIKE ?", "rob%")
+	if err != nil {
+		t.Fatalf("FindWhere failed: %v", err)
+	}
+	if len(users) != 1 || users[0].ID != bob.ID {
+		t.Errorf("Expected the renamed user, got %+v", users)
+	}
+
+	missing := repoUser{ID: 99, Name: "nobody"}
+	if err := repo.Update(ctx, &missing); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows updating a missing row, got %v", err)
+	}
+}
+
+func TestRepositorySoftDelete(t *testing.T) {
+	d, repo := setupRepositoryTest(t)
+	ctx := context.Background()
+
+	user := repoUser{Name: "alice"}
+	if err := repo.Insert(ctx, &user); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	if err := repo.Delete(ctx, user.ID); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+
+	if _, err := repo.FindByID(ctx, user.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected a deleted row to be hidden, got %v", err)
+	}
+
+	if count, err := repo.Count(ctx, ""); err != nil || count != 0 {
+		t.Errorf("Expected a count of 0, got %d (%v)", count, err)
+	}
+
+	if err := repo.Delete(ctx, user.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected deleting twice to fail, got %v", err)
+	}
+
+	var rows int
+	d.QueryRow("SELECT COUNT(*) FROM users WHERE deleted_at IS NOT NULL").Scan(&rows)
+	if rows != 1 {
+		t.Errorf("Expected the row to be kept, got %d", rows)
+	}
+}
+
+func TestRepositoryExplicitKeyAndTx(t *testing.T) {
+	d, _ := setupRepositoryTest(t)
+	ctx := context.Background()
+
+	repo, err := NewRepository[repoTag](d)
+	if err != nil {
+		t.Fatalf("NewRepository failed: %v", err)
+	}
+	if repo.Table() != "repo_tag" {
+		t.Errorf("Expected the table name derived from the type, got %s", repo.Table())
+	}
+
+	err = d.WithTx(ctx, nil, func(tx DBOrTx) error {
+		if err := repo.WithTx(tx).Insert(ctx, &repoTag{Code: "go", Label: "Go"}); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+	if err == nil || err.Error() != "abort" {
+		t.Fatalf("Expected the transaction to be aborted, got %v", err)
+	}
+
+	if err := repo.Insert(ctx, &repoTag{Code: "sql", Label: "SQL"}); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+
+	if count, _ := repo.Count(ctx, "id IN (?, ?)", "go", "sql"); count != 1 {
+		t.Errorf("Expected only the committed row, got %d", count)
+	}
+
+	if err := repo.Delete(ctx, "sql"); err != nil {
+		t.Errorf("Delete failed: %v", err)
+	}
+}
+
+func TestNewRepositoryErrors(t *testing.T) {
+	d := getTestDb(t, DbConnectOptions{})
+
+	type noKey struct {
+		Name string
+	}
+	if _, err := NewRepository[noKey](d); err == nil {
+		t.Error("Expected an error for a type without primary key")
+	}
+
+	type badTimestamp struct {
+		ID      int64  `db:"id"`
+		Created string `db:"created,created"`
+	}
+	if _, err := NewRepository[badTimestamp](d); err == nil {
+		t.Error("Expected an error for a timestamp column that is no time")
+	}
+}
+
+func TestRepositoryInsertReturning(t *testing.T) {
+	mockDb, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer mockDb.Close()
+
+	d := &Db{DbObj: &postgres.PgSqlDb{DbObj: mockDb}, DbType: "postgres"}
+	repo, err := NewRepository[repoTag](d)
+	if err != nil {
+		t.Fatalf("NewRepository failed: %v", err)
+	}
+
+	type serial struct {
+		_     struct{} `table:"items"`
+		ID    int32    `db:"id,pk"`
+		Label string   `db:"label"`
+	}
+	serialRepo, err := NewRepository[serial](d)
+	if err != nil {
+		t.Fatalf("NewRepository failed: %v", err)
+	}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "items" ("label") VALUES ($1) RETURNING "id"`)).
+		WithArgs("a").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id", "label" FROM "repo_tag" WHERE "id" = $1`)).
+		WithArgs("go").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "label"}).AddRow("go", "Go"))
+
+	item := serial{Label: "a"}
+	if err := serialRepo.Insert(context.Background(), &item); err != nil {
+		t.Fatalf("Insert failed: %v", err)
+	}
+	if item.ID != 7 {
+		t.Errorf("Expected the returned id 7, got %d", item.ID)
+	}
+
+	if _, err := repo.FindByID(context.Background(), "go"); err != nil {
+		t.Fatalf("FindByID failed: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
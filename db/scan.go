@@ -0,0 +1,292 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// UnmatchedColumnsError is returned when a query returns columns without a matching struct field
+type UnmatchedColumnsError struct {
+	Type    string
+	Columns []string
+}
+
+func (e *UnmatchedColumnsError) Error() string {
+	return fmt.Sprintf("no field in %s for column(s) %s", e.Type, strings.Join(e.Columns, ", "))
+}
+
+// Select runs query and scans every row into a T.
+// columns are matched to fields by their db tag (`db:"column_name"`) or the snake_case field name.
+// fields of embedded structs are matched as if they were declared in T, `db:"-"` skips a field.
+// use pointer fields or sql.Null* types for nullable columns.
+// if T is not a struct the query has to return a single column which is scanned into T directly
+func Select[T any](ctx context.Context, dbOrTx DBOrTx, query string, args ...any) ([]T, error) {
+	rows, err := dbOrTx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return ScanRows[T](rows)
+}
+
+// Get runs query and scans the first row into a T. sql.ErrNoRows is returned if the query has no result
+func Get[T any](ctx context.Context, dbOrTx DBOrTx, query string, args ...any) (T, error) {
+	var result T
+
+	rows, err := dbOrTx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return result, err
+		}
+		return result, sql.ErrNoRows
+	}
+
+	scanner, err := newRowScanner[T](rows)
+	if err != nil {
+		return result, err
+	}
+
+	if err := scanner.scan(rows, &result); err != nil {
+		return result, err
+	}
+
+	return result, rows.Close()
+}
+
+// ScanRows scans all remaining rows into a slice of T and closes rows. see Select for the mapping rules
+func ScanRows[T any](rows *sql.Rows) ([]T, error) {
+	defer rows.Close()
+
+	results := []T{}
+	var scanner *rowScanner
+
+	for rows.Next() {
+		if scanner == nil {
+			var err error
+			scanner, err = newRowScanner[T](rows)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		var result T
+		if err := scanner.scan(rows, &result); err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// rowScanner holds the field index paths for the columns of a result set
+type rowScanner struct {
+	direct bool
+	fields [][]int
+}
+
+func newRowScanner[T any](rows *sql.Rows) (*rowScanner, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	t := reflect.TypeFor[T]()
+
+	if !isStructMapping(t) {
+		if len(columns) != 1 {
+			return nil, fmt.Errorf("scanning into %s requires exactly one column, got %d", t, len(columns))
+		}
+		return &rowScanner{direct: true}, nil
+	}
+
+	fieldMap := structFieldMap(t)
+
+	scanner := &rowScanner{}
+	var unmatched []string
+	for _, column := range columns {
+		index, ok := fieldMap[strings.ToLower(column)]
+		if !ok {
+			unmatched = append(unmatched, column)
+			continue
+		}
+		scanner.fields = append(scanner.fields, index)
+	}
+
+	if len(unmatched) > 0 {
+		return nil, &UnmatchedColumnsError{Type: t.String(), Columns: unmatched}
+	}
+
+	return scanner, nil
+}
+
+func (s *rowScanner) scan(rows *sql.Rows, dest any) error {
+	if s.direct {
+		return rows.Scan(dest)
+	}
+
+	v := reflect.ValueOf(dest).Elem()
+	targets := make([]any, len(s.fields))
+	for i, index := range s.fields {
+		targets[i] = fieldByIndexAlloc(v, index).Addr().Interface()
+	}
+
+	return rows.Scan(targets...)
+}
+
+// fieldByIndexAlloc works like reflect.Value.FieldByIndex but allocates nil embedded struct pointers
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, fieldIndex := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(fieldIndex)
+	}
+
+	return v
+}
+
+var (
+	scannerType  = reflect.TypeFor[sql.Scanner]()
+	timeType     = reflect.TypeFor[time.Time]()
+	fieldMapLock sync.RWMutex
+	fieldMaps    = map[reflect.Type]map[string][]int{}
+)
+
+// isStructMapping reports whether t is scanned field by field instead of as a single value
+func isStructMapping(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == timeType {
+		return false
+	}
+
+	return !reflect.PointerTo(t).Implements(scannerType)
+}
+
+// structFieldMap returns the lower case column names of t mapped to their field index paths
+func structFieldMap(t reflect.Type) map[string][]int {
+	fieldMapLock.RLock()
+	fieldMap, ok := fieldMaps[t]
+	fieldMapLock.RUnlock()
+	if ok {
+		return fieldMap
+	}
+
+	fieldMap = map[string][]int{}
+	for _, field := range structFields(t) {
+		// fields declared closer to the surface win over fields of embedded structs
+		if _, exists := fieldMap[field.Column]; !exists {
+			fieldMap[field.Column] = field.Index
+		}
+	}
+
+	fieldMapLock.Lock()
+	fieldMaps[t] = fieldMap
+	fieldMapLock.Unlock()
+
+	return fieldMap
+}
+
+// structField is a mapped struct field with the options of its db tag
+type structField struct {
+	Column  string
+	Index   []int
+	Options []string
+}
+
+// structFields lists the mapped fields of t in declaration order, breadth first through embedded structs
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	var embedded []structField
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("db")
+		if tag == "-" {
+			continue
+		}
+
+		// exported fields of unexported embedded structs are promoted and therefore settable,
+		// unexported embedded pointers however can not be allocated
+		if !field.IsExported() && !(field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		var optionList []string
+		if options != "" {
+			optionList = strings.Split(options, ",")
+		}
+
+		if field.Anonymous && name == "" {
+			structType := field.Type
+			if structType.Kind() == reflect.Pointer {
+				structType = structType.Elem()
+			}
+			if isStructMapping(structType) {
+				for _, nested := range structFields(structType) {
+					nested.Index = append([]int{i}, nested.Index...)
+					embedded = append(embedded, nested)
+				}
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = ToSnakeCase(field.Name)
+		}
+
+		fields = append(fields, structField{
+			Column:  strings.ToLower(name),
+			Index:   []int{i},
+			Options: optionList,
+		})
+	}
+
+	return append(fields, embedded...)
+}
+
+// ToSnakeCase converts a go identifier like UserID or HTTPServer into user_id and http_server
+func ToSnakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				prev := runes[i-1]
+				nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+				if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+					sb.WriteRune('_')
+				}
+			}
+			sb.WriteRune(unicode.ToLower(r))
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,131 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+type scanTestBase struct {
+	ID        int64
+	CreatedAt time.Time
+}
+
+type scanTestUser struct {
+	scanTestBase
+	Name     string         `db:"user_name"`
+	Email    *string        // NULL becomes nil
+	Nickname sql.NullString // sql.Scanner
+	Ignored  string         `db:"-"`
+}
+
+func setupScanTestTable(t *testing.T) *Db {
+	t.Helper()
+
+	d := getTestDb(t, DbConnectOptions{})
+	queries := []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, user_name TEXT NOT NULL, email TEXT NULL, nickname TEXT NULL, created_at DATETIME NOT NULL)",
+		"INSERT INTO users (id, user_name, email, nickname, created_at) VALUES (1, 'max', 'max@test.com', NULL, '2025-01-02 03:04:05')",
+		"INSERT INTO users (id, user_name, email, nickname, created_at) VALUES (2, 'erika', NULL, 'eri', '2025-02-03 04:05:06')",
+	}
+	for _, query := range queries {
+		if _, err := d.Exec(query); err != nil {
+			t.Fatalf("Setting up users failed: %v", err)
+		}
+	}
+
+	return d
+}
+
+func TestSelectStructs(t *testing.T) {
+	d := setupScanTestTable(t)
+
+	users, err := Select[scanTestUser](context.Background(), d, "SELECT id, user_name, email, nickname, created_at FROM users ORDER BY id")
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+
+	if len(users) != 2 {
+		t.Fatalf("Expected 2 users, got %d", len(users))
+	}
+
+	first := users[0]
+	if first.ID != 1 || first.Name != "max" || first.Email == nil || *first.Email != "max@test.com" || first.Nickname.Valid {
+		t.Errorf("Unexpected first user: %+v", first)
+	}
+
+	if first.CreatedAt.Year() != 2025 {
+		t.Errorf("Expected created_at to be parsed, got %v", first.CreatedAt)
+	}
+
+	second := users[1]
+	if second.Email != nil || !second.Nickname.Valid || second.Nickname.String != "eri" {
+		t.Errorf("Unexpected second user: %+v", second)
+	}
+}
+
+func TestSelectScalar(t *testing.T) {
+	d := setupScanTestTable(t)
+
+	names, err := Select[string](context.Background(), d, "SELECT user_name FROM users ORDER BY id")
+	if err != nil {
+		t.Fatalf("Select failed: %v", err)
+	}
+
+	if len(names) != 2 || names[0] != "max" || names[1] != "erika" {
+		t.Errorf("Expected [max erika], got %v", names)
+	}
+}
+
+func TestSelectUnmatchedColumns(t *testing.T) {
+	d := setupScanTestTable(t)
+
+	_, err := Select[scanTestUser](context.Background(), d, "SELECT id, user_name, 1 AS unknown_column FROM users")
+
+	var unmatchedErr *UnmatchedColumnsError
+	if !errors.As(err, &unmatchedErr) {
+		t.Fatalf("Expected an UnmatchedColumnsError, got %v", err)
+	}
+
+	if len(unmatchedErr.Columns) != 1 || unmatchedErr.Columns[0] != "unknown_column" {
+		t.Errorf("Expected unknown_column to be reported, got %v", unmatchedErr.Columns)
+	}
+}
+
+func TestGet(t *testing.T) {
+	d := setupScanTestTable(t)
+	ctx := context.Background()
+
+	user, err := Get[scanTestUser](ctx, d, "SELECT id, user_name FROM users WHERE id = ?", 2)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+
+	if user.Name != "erika" {
+		t.Errorf("Expected erika, got %s", user.Name)
+	}
+
+	_, err = Get[scanTestUser](ctx, d, "SELECT id, user_name FROM users WHERE id = ?", 99)
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func TestToSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"ID":         "id",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"CreatedAt":  "created_at",
+		"Address2":   "address2",
+		"name":       "name",
+	}
+
+	for input, expected := range tests {
+		if result := ToSnakeCase(input); result != expected {
+			t.Errorf("ToSnakeCase(%q): expected %q, got %q", input, expected, result)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package db
+
+import (
+	"errors"
+	"strings"
+)
+
+// SplitScript splits a script into statements like the mysql client does, since the driver only
+// executes several statements at once with multiStatements=true. quotes with backslash escapes and
+// -- , # and /* */ comments are skipped. procedures and triggers with BEGIN ... END need a
+// DELIMITER line before them, for example DELIMITER $$, like in the mysql client
+func (mysqlDialect) SplitScript(script string) ([]string, error) {
+	var statements []string
+	delimiter := ";"
+	start := 0
+	// hasCode is false while the current statement only holds whitespace and comments
+	hasCode := false
+
+	for i := 0; i < len(script); {
+		c := script[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+			continue
+		case c == '\'' || c == '"' || c == '`':
+			end, err := skipMysqlQuote(script, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end
+			hasCode = true
+			continue
+		case c == '#' || (c == '-' && isMysqlLineComment(script[i:])):
+			i = skipLine(script, i)
+			continue
+		case c == '/' && strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end < 0 {
+				return nil, errors.New("unterminated comment in mysql script")
+			}
+			i += end + 4
+			continue
+		case !hasCode && hasPrefixFold(script[i:], "DELIMITER") && i+9 < len(script) && (script[i+9] == ' ' || script[i+9] == '\t'):
+			end := skipLine(script, i)
+			delimiter = strings.TrimSpace(script[i+9 : end])
+			if delimiter == "" {
+				return nil, errors.New("DELIMITER without delimiter in mysql script")
+			}
+			i = end
+			start = end
+			continue
+		case strings.HasPrefix(script[i:], delimiter):
+			if hasCode {
+				statements = append(statements, strings.TrimSpace(script[start:i]))
+			}
+			i += len(delimiter)
+			start = i
+			hasCode = false
+			continue
+		}
+
+		hasCode = true
+		i++
+	}
+
+	if hasCode {
+		statements = append(statements, strings.TrimSpace(script[start:]))
+	}
+
+	return statements, nil
+}
+
+// skipMysqlQuote returns the index after the string or quoted identifier starting at start.
+// quotes are escaped by doubling them and, except in identifiers, by a backslash
+func skipMysqlQuote(script string, start int) (int, error) {
+	quote := script[start]
+
+	for i := start + 1; i < len(script); i++ {
+		switch script[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			if i+1 < len(script) && script[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1, nil
+		}
+	}
+
+	return 0, errors.New("unterminated quote in mysql script")
+}
+
+// isMysqlLineComment reports if s starts with -- followed by whitespace, mysql does not treat --x as a comment
+func isMysqlLineComment(s string) bool {
+	if !strings.HasPrefix(s, "--") {
+		return false
+	}
+
+	return len(s) == 2 || s[2] == ' ' || s[2] == '\t' || s[2] == '\r' || s[2] == '\n'
+}
+
+// skipLine returns the index of the line break ending the line of i or the end of script
+func skipLine(script string, i int) int {
+	if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+		return i + end
+	}
+
+	return len(script)
+}
+
+func hasPrefixFold(s string, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
@@ -1,15 +1,27 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"github.com/MathiasMantai/gotools/cli"
+	"github.com/MathiasMantai/gotools/db/dblog"
+	"github.com/MathiasMantai/gotools/db/util"
 	"strings"
 )
 
 type MigrationRunner struct {
 	Migrations []Migration
 	Db         *SqliteDb
+
+	// defaults to the logger of Db
+	Logger dblog.Logger
+}
+
+func (mr *MigrationRunner) log() dblog.Logger {
+	if mr.Db == nil {
+		return dblog.Or(mr.Logger)
+	}
+	return dblog.Or(mr.Logger, mr.Db.Logger)
 }
 
 func (mr *MigrationRunner) Run() error {
@@ -19,11 +31,10 @@ func (mr *MigrationRunner) Run() error {
 	}
 
 	for key, migration := range mr.Migrations {
-		migrationText := fmt.Sprintf("migration %d - %s", key, migration.TableName)
-		cli.PrintWithTimeAndColor("=> attempting to apply "+migrationText, "blue", true)
+		mr.log().Debug("attempting to apply migration", "migration", key, "table", migration.TableName)
 
 		if len(migration.Fields) == 0 {
-			cli.PrintWithTimeAndColor(fmt.Sprintf("=> skipping %v since no fields were declared for table", migrationText), "yellow", true)
+			mr.log().Warn("skipping migration without fields", "migration", key, "table", migration.TableName)
 			continue
 		}
 
@@ -33,39 +44,31 @@ func (mr *MigrationRunner) Run() error {
 		}
 
 		if !applied {
-			tx, err := mr.Db.DbObj.Begin()
-			if err != nil {
-				return fmt.Errorf("error starting transaction for migration %s: %v", migration.TableName, err.Error())
-			}
-
-			createQuery := migration.CreateQuery()
-			fmt.Println(createQuery)
-			_, err = tx.Exec(createQuery)
-			if err != nil {
-				tx.Rollback()
-				return fmt.Errorf("error creating table %s: %v", migration.TableName, err.Error())
-			}
-
-			// Migration loggen
-			err = mr.LogMigrationTx(tx, migration.TableName, migration.Description)
-			if err != nil {
-				tx.Rollback()
-				return fmt.Errorf("error logging table %s: %v", migration.TableName, err.Error())
-			}
-
-			err = tx.Commit()
+			err := util.WithTx(context.Background(), mr.Db.DbObj, func(tx *sql.Tx) error {
+				createQuery := migration.CreateQuery()
+				mr.log().Debug("migration sql", "table", migration.TableName, "sql", createQuery)
+				if _, err := tx.Exec(createQuery); err != nil {
+					return fmt.Errorf("error creating table %s: %v", migration.TableName, err.Error())
+				}
+
+				if err := mr.LogMigrationTx(tx, migration.TableName, migration.Description); err != nil {
+					return fmt.Errorf("error logging table %s: %v", migration.TableName, err.Error())
+				}
+
+				return nil
+			})
 			if err != nil {
-				return fmt.Errorf("error committing transaction for migration %s: %v", migration.TableName, err.Error())
+				return err
 			}
 
-			cli.PrintWithTimeAndColor("=> migration successfully applied and logged", "green", true)
+			mr.log().Info("migration applied", "migration", key, "table", migration.TableName)
 		} else {
-			cli.PrintWithTimeAndColor(fmt.Sprintf("=> %v already applied. Skipping...", migrationText), "yellow", true)
+			mr.log().Debug("migration already applied", "migration", key, "table", migration.TableName)
 		}
 	}
 
 	if len(mr.Migrations) == 0 {
-		cli.PrintWithTimeAndColor("=> no migrations to apply", "yellow", true)
+		mr.log().Info("no migrations to apply")
 	}
 	return nil
 }
@@ -204,3 +207,29 @@ func (m *Migration) CreateQuery() string {
 		)
 	`, m.TableName, fieldsString)
 }
+
+// MapSqliteTypeToGo returns the Go type a column of the declared sqliteType is scanned into.
+// it follows the type affinity rules of sqlite and the time types parsed by go-sqlite3
+func MapSqliteTypeToGo(sqliteType string) string {
+	sqliteType = strings.ToUpper(strings.TrimSpace(sqliteType))
+
+	switch {
+	case sqliteType == "":
+		return "any"
+	case strings.HasPrefix(sqliteType, "BOOL"):
+		return "bool"
+	case strings.HasPrefix(sqliteType, "DATE"), strings.HasPrefix(sqliteType, "TIMESTAMP"):
+		return "time.Time"
+	case strings.Contains(sqliteType, "INT"):
+		return "int64"
+	case strings.Contains(sqliteType, "CHAR"), strings.Contains(sqliteType, "CLOB"), strings.Contains(sqliteType, "TEXT"):
+		return "string"
+	case strings.Contains(sqliteType, "BLOB"):
+		return "[]byte"
+	case strings.Contains(sqliteType, "REAL"), strings.Contains(sqliteType, "FLOA"), strings.Contains(sqliteType, "DOUB"),
+		strings.Contains(sqliteType, "NUMERIC"), strings.Contains(sqliteType, "DECIMAL"):
+		return "float64"
+	default:
+		return "any"
+	}
+}
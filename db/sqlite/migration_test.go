@@ -320,3 +320,21 @@ func TestCreateQueryAutoIncrement(t *testing.T) {
 		t.Errorf("Expected query for non-integer PK NOT to contain 'AUTOINCREMENT', but it did:\n%s", queryNonInteger)
 	}
 }
+
+func TestMapSqliteTypeToGo(t *testing.T) {
+	tests := map[string]string{
+		"INTEGER":      "int64",
+		"VARCHAR(255)": "string",
+		"BLOB":         "[]byte",
+		"DOUBLE":       "float64",
+		"DATETIME":     "time.Time",
+		"BOOLEAN":      "bool",
+		"":             "any",
+	}
+
+	for sqliteType, expected := range tests {
+		if result := MapSqliteTypeToGo(sqliteType); result != expected {
+			t.Errorf("MapSqliteTypeToGo(%q): expected %s, got %s", sqliteType, expected, result)
+		}
+	}
+}
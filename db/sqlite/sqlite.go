@@ -3,13 +3,18 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"embed"
 	"fmt"
-	"github.com/MathiasMantai/gotools/cli"
-	_ "github.com/mattn/go-sqlite3"
-	"log"
+	"github.com/MathiasMantai/gotools/db/dblog"
+	"github.com/mattn/go-sqlite3"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type DbConnData struct {
@@ -23,6 +28,46 @@ type DbConnData struct {
 type SqliteDb struct {
 	DbObj    *sql.DB
 	FilePath string
+	Logger   dblog.Logger
+}
+
+// Options configures ConnectWithOptions
+type Options struct {
+	// parameters added to the connection string like _busy_timeout
+	Params map[string]string
+
+	// receives the messages of the connection and its migration runners. nil discards them
+	Logger dblog.Logger
+
+	// InMemory keeps the database in memory. without SharedCache every connection has its own
+	// database, so the pool is limited to a single connection
+	InMemory bool
+
+	// SharedCache lets the connections of the pool share one cache. together with InMemory
+	// every connection of the process opening the same file path uses the same database
+	SharedCache bool
+
+	// ReadOnly opens an existing database without write access
+	ReadOnly bool
+
+	// ForeignKeys enforces foreign key constraints which sqlite ignores by default
+	ForeignKeys bool
+
+	// BusyTimeout is how long a statement waits for locks of other connections. zero keeps the driver default of 5s
+	BusyTimeout time.Duration
+
+	// Synchronous is OFF, NORMAL, FULL or EXTRA. empty keeps the driver default NORMAL
+	Synchronous string
+
+	// CacheSize is the cache size of each connection like PRAGMA cache_size:
+	// a number of pages if positive and kibibytes if negative. zero keeps the default
+	CacheSize int
+
+	// JournalMode defaults to WAL. read-only and in memory databases keep their journal mode unless it is set
+	JournalMode string
+
+	// Pragmas are executed without the PRAGMA keyword on every new connection of the pool, for example "temp_store = MEMORY"
+	Pragmas []string
 }
 
 func (mdb *SqliteDb) BeginTx(ctx context.Context, options *sql.TxOptions) (*sql.Tx, error) {
@@ -41,43 +86,186 @@ func (mdb *SqliteDb) QueryRow(query string, args ...any) *sql.Row {
 	return mdb.DbObj.QueryRow(query, args...)
 }
 
+func (mdb *SqliteDb) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return mdb.DbObj.ExecContext(ctx, query, args...)
+}
+
+func (mdb *SqliteDb) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return mdb.DbObj.QueryContext(ctx, query, args...)
+}
+
+func (mdb *SqliteDb) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return mdb.DbObj.QueryRowContext(ctx, query, args...)
+}
+
+func (mdb *SqliteDb) PingContext(ctx context.Context) error {
+	return mdb.DbObj.PingContext(ctx)
+}
+
+// DB returns the underlying connection pool
+func (mdb *SqliteDb) DB() *sql.DB {
+	return mdb.DbObj
+}
+
 func Connect(filePath string) (*SqliteDb, error) {
+	return ConnectWithParams(filePath, nil)
+}
+
+// ConnectWithParams opens the database at filePath and appends params to the connection string
+func ConnectWithParams(filePath string, params map[string]string) (*SqliteDb, error) {
+	return ConnectWithOptions(filePath, Options{Params: params})
+}
+
+// ConnectWithOptions opens the database at filePath. for in memory databases filePath names
+// the database shared with SharedCache and may be empty otherwise
+func ConnectWithOptions(filePath string, options Options) (*SqliteDb, error) {
 	var db SqliteDb
 	db.FilePath = filePath
+	db.Logger = options.Logger
+	log := dblog.Or(db.Logger)
 
 	dir := filepath.Dir(filePath)
-	if dir != "." && dir != "" {
+	if !options.InMemory && !options.ReadOnly && dir != "." && dir != "" {
 		if err := os.MkdirAll(dir, 0755); err != nil {
 			return nil, fmt.Errorf("failed to create directory: %w", err)
 		}
 	}
 
-	cli.PrintWithTimeAndColor("=> establishing Database connection with database at path "+filePath, "green", true)
+	log.Info("establishing database connection", "path", filePath)
 
-	connStr := fmt.Sprintf("%s?mode=rwc&_journal_mode=WAL", filePath)
+	dbObj := sql.OpenDB(&connector{
+		dsn:    options.dsn(filePath),
+		driver: &sqlite3.SQLiteDriver{ConnectHook: options.connectHook()},
+	})
 
-	dbObj, ConnError := sql.Open("sqlite3", connStr)
-	if ConnError != nil {
-		return nil, ConnError
+	if options.InMemory && !options.SharedCache {
+		dbObj.SetMaxOpenConns(1)
+		// closing the last connection drops the database
+		dbObj.SetConnMaxLifetime(0)
+		dbObj.SetConnMaxIdleTime(0)
 	}
 
 	if err := dbObj.Ping(); err != nil {
+		dbObj.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
 	db.DbObj = dbObj
 
-	cli.PrintWithTimeAndColor("=> Database connection established successfully", "green", true)
+	log.Debug("database connection established", "path", filePath)
 	return &db, nil
 }
 
+// FormatDSN creates the connection string for go-sqlite3. params overwrite the default mode and journal mode
+func FormatDSN(filePath string, params map[string]string) string {
+	query := url.Values{}
+	query.Set("mode", "rwc")
+	query.Set("_journal_mode", "WAL")
+
+	for key, value := range params {
+		query.Set(key, value)
+	}
+
+	return fmt.Sprintf("%s?%s", filePath, query.Encode())
+}
+
+// dsn creates the connection string for the options. Params overwrite the parameters set by the other options
+func (o Options) dsn(filePath string) string {
+	query := url.Values{}
+
+	switch {
+	case o.InMemory:
+		query.Set("mode", "memory")
+	case o.ReadOnly:
+		query.Set("mode", "ro")
+	default:
+		query.Set("mode", "rwc")
+	}
+
+	if o.SharedCache {
+		query.Set("cache", "shared")
+	}
+
+	if o.JournalMode != "" {
+		query.Set("_journal_mode", o.JournalMode)
+	} else if !o.InMemory && !o.ReadOnly {
+		query.Set("_journal_mode", "WAL")
+	}
+
+	if o.ForeignKeys {
+		query.Set("_foreign_keys", "1")
+	}
+	if o.BusyTimeout > 0 {
+		query.Set("_busy_timeout", strconv.FormatInt(o.BusyTimeout.Milliseconds(), 10))
+	}
+	if o.Synchronous != "" {
+		query.Set("_synchronous", strings.ToUpper(o.Synchronous))
+	}
+	if o.CacheSize != 0 {
+		query.Set("_cache_size", strconv.Itoa(o.CacheSize))
+	}
+
+	for key, value := range o.Params {
+		query.Set(key, value)
+	}
+
+	name := filePath
+	if o.InMemory && name == "" {
+		name = ":memory:"
+	}
+
+	// mode and cache are only read from uri file names
+	if !strings.HasPrefix(name, "file:") && (o.InMemory || o.ReadOnly || o.SharedCache) {
+		name = "file:" + strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(name)
+	}
+
+	return fmt.Sprintf("%s?%s", name, query.Encode())
+}
+
+// connectHook runs the pragmas on every new connection
+func (o Options) connectHook() func(*sqlite3.SQLiteConn) error {
+	if len(o.Pragmas) == 0 {
+		return nil
+	}
+
+	pragmas := append([]string{}, o.Pragmas...)
+	return func(conn *sqlite3.SQLiteConn) error {
+		for _, pragma := range pragmas {
+			if _, err := conn.Exec("PRAGMA "+pragma, nil); err != nil {
+				return fmt.Errorf("pragma %s failed: %w", pragma, err)
+			}
+		}
+		return nil
+	}
+}
+
+// connector opens connections of a driver with a connect hook without registering it globally
+type connector struct {
+	dsn    string
+	driver *sqlite3.SQLiteDriver
+}
+
+func (c *connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.dsn)
+}
+
+func (c *connector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Migrate executes every file in migrationDir in the order of the file names.
+// nothing is recorded, so the files run again on every call. see db.Migrator for versioned migrations
 func (s *SqliteDb) Migrate(migrationDir string) error {
 	dir, readDirError := os.ReadDir(migrationDir)
 	if readDirError != nil {
-		log.Fatal("=> Migration")
+		return fmt.Errorf("reading migration directory %s failed: %w", migrationDir, readDirError)
 	}
 
 	for _, fileName := range dir {
+		if fileName.IsDir() {
+			continue
+		}
+
 		file, err := os.ReadFile(filepath.Join(migrationDir, fileName.Name()))
 		if err != nil {
 			return err
@@ -92,25 +280,31 @@ func (s *SqliteDb) Migrate(migrationDir string) error {
 	return nil
 }
 
-// run migration from an embedded directory
+// run migration from the directory dirName of an embedded file system
 // reads every migrationfile separately and executes all qureries
 func (s *SqliteDb) MigrateEmbedded(migrationDir embed.FS, dirName string) error {
-	fmt.Println("=> Running migrations...")
-	dir, readDirError := migrationDir.ReadDir("migrations")
+	logger := dblog.Or(s.Logger)
+	logger.Debug("running migrations", "dir", dirName)
+	dir, readDirError := migrationDir.ReadDir(dirName)
 	if readDirError != nil {
-		log.Fatal("=> Migration dir could not be found")
+		logger.Error("reading migration directory failed", "dir", dirName, "error", readDirError)
+		return fmt.Errorf("reading migration directory %s failed: %w", dirName, readDirError)
 	}
 
 	for _, fileName := range dir {
-		fmt.Printf("=> Running migration: %v\n", fileName.Name())
-		var filePath string = dirName + "/" + fileName.Name()
-		file, err := migrationDir.ReadFile(filePath)
+		if fileName.IsDir() {
+			continue
+		}
+
+		logger.Debug("running migration", "file", fileName.Name())
+		// embed.FS always uses forward slashes
+		file, err := migrationDir.ReadFile(path.Join(dirName, fileName.Name()))
 		if err != nil {
-			fmt.Printf("x> Error executing migration %v: %v\n", fileName.Name(), err.Error())
+			logger.Error("reading migration failed", "file", fileName.Name(), "error", err)
 			return err
 		}
 
-		fmt.Println("=> " + string(file))
+		logger.Debug("migration sql", "file", fileName.Name(), "sql", string(file))
 
 		_, queryError := s.DbObj.Exec(string(file))
 		if queryError != nil {
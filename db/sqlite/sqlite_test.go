@@ -0,0 +1,164 @@
+package sqlite
+
+import (
+	"embed"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOptionsDSN(t *testing.T) {
+	tests := []struct {
+		name     string
+		filePath string
+		options  Options
+		expected string
+	}{
+		{"defaults", "app.db", Options{}, "app.db?_journal_mode=WAL&mode=rwc"},
+		{"private memory", "", Options{InMemory: true}, "file::memory:?mode=memory"},
+		{"shared memory", "cache", Options{InMemory: true, SharedCache: true}, "file:cache?cache=shared&mode=memory"},
+		{"read only", "data/app?.db", Options{ReadOnly: true}, "file:data/app%3f.db?mode=ro"},
+		{"uri kept", "file:app.db", Options{ReadOnly: true}, "file:app.db?mode=ro"},
+		{
+			"pragmas",
+			"app.db",
+			Options{ForeignKeys: true, BusyTimeout: 2 * time.Second, Synchronous: "full", CacheSize: -2000},
+			"app.db?_busy_timeout=2000&_cache_size=-2000&_foreign_keys=1&_journal_mode=WAL&_synchronous=FULL&mode=rwc",
+		},
+		{"params win", "app.db", Options{ForeignKeys: true, Params: map[string]string{"_foreign_keys": "0"}}, "app.db?_foreign_keys=0&_journal_mode=WAL&mode=rwc"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.options.dsn(test.filePath); actual != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestConnectInMemory(t *testing.T) {
+	db, err := ConnectWithOptions("", Options{InMemory: true})
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer closeTestDb(t, db)
+
+	if _, err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatalf("create table failed: %v", err)
+	}
+
+	// every statement has to see the same database
+	for i := 0; i < 3; i++ {
+		if _, err := db.Exec("INSERT INTO items DEFAULT VALUES"); err != nil {
+			t.Fatalf("insert failed: %v", err)
+		}
+	}
+}
+
+func TestConnectForeignKeysAndPragmas(t *testing.T) {
+	db, err := ConnectWithOptions(filepath.Join(t.TempDir(), "test.db"), Options{
+		ForeignKeys: true,
+		Pragmas:     []string{"temp_store = MEMORY", "user_version = 7"},
+	})
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer closeTestDb(t, db)
+
+	var tempStore, userVersion int
+	if err := db.QueryRow("PRAGMA temp_store").Scan(&tempStore); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow("PRAGMA user_version").Scan(&userVersion); err != nil {
+		t.Fatal(err)
+	}
+	if tempStore != 2 || userVersion != 7 {
+		t.Errorf("expected temp_store 2 and user_version 7, got %d and %d", tempStore, userVersion)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE parents (id INTEGER PRIMARY KEY);
+		CREATE TABLE children (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES parents(id));
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Exec("INSERT INTO children (parent_id) VALUES (1)"); err == nil {
+		t.Errorf("expected a foreign key violation")
+	}
+}
+
+func TestConnectInvalidPragma(t *testing.T) {
+	_, err := ConnectWithOptions(filepath.Join(t.TempDir(), "test.db"), Options{Pragmas: []string{"not valid sql"}})
+	if err == nil || !strings.Contains(err.Error(), "pragma not valid sql failed") {
+		t.Errorf("expected a pragma error, got %v", err)
+	}
+}
+
+func TestConnectReadOnly(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "test.db")
+
+	db, err := ConnectWithOptions(filePath, Options{})
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	if _, err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY)"); err != nil {
+		t.Fatal(err)
+	}
+	closeTestDb(t, db)
+
+	readOnly, err := ConnectWithOptions(filePath, Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("read only connect failed: %v", err)
+	}
+	defer closeTestDb(t, readOnly)
+
+	var count int
+	if err := readOnly.QueryRow("SELECT COUNT(*) FROM items").Scan(&count); err != nil {
+		t.Errorf("read failed: %v", err)
+	}
+	if _, err := readOnly.Exec("INSERT INTO items DEFAULT VALUES"); err == nil {
+		t.Errorf("expected the insert to fail on a read only database")
+	}
+}
+
+//go:embed testdata/schema
+var schemaFS embed.FS
+
+func TestMigrateEmbeddedUsesDirName(t *testing.T) {
+	db, err := ConnectWithOptions("", Options{InMemory: true})
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer closeTestDb(t, db)
+
+	if err := db.MigrateEmbedded(schemaFS, "testdata/schema"); err != nil {
+		t.Fatalf("MigrateEmbedded failed: %v", err)
+	}
+
+	var count int
+	if err := db.QueryRow("SELECT COUNT(*) FROM embedded_items").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 row, got %d", count)
+	}
+}
+
+func TestMigrateMissingDir(t *testing.T) {
+	db, err := ConnectWithOptions("", Options{InMemory: true})
+	if err != nil {
+		t.Fatalf("connect failed: %v", err)
+	}
+	defer closeTestDb(t, db)
+
+	if err := db.MigrateEmbedded(schemaFS, "testdata/missing"); err == nil {
+		t.Errorf("expected an error for a missing embedded directory")
+	}
+	if err := db.Migrate(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("expected an error for a missing directory")
+	}
+}
@@ -0,0 +1 @@
+CREATE TABLE IF NOT EXISTS embedded_items (id INTEGER PRIMARY KEY, name TEXT);
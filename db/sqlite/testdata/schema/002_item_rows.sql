@@ -0,0 +1 @@
+INSERT INTO embedded_items (name) VALUES ('first');
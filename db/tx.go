@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/MathiasMantai/gotools/db/util"
+)
+
+// TxRunner is implemented by Db and Tx. inside a WithTx callback the DBOrTx can be
+// asserted to a TxRunner to open a nested transaction, which is backed by a savepoint
+type TxRunner interface {
+	WithTx(ctx context.Context, options *sql.TxOptions, fn func(tx DBOrTx) error) error
+}
+
+// Tx is the transaction handed to the callback of WithTx
+type Tx struct {
+	Tx     *sql.Tx
+	DbType string
+
+	// nesting level. 0 for the outermost transaction
+	depth int
+
+	hooks []Hook
+}
+
+var (
+	_ DBOrTx   = (*Tx)(nil)
+	_ TxRunner = (*Tx)(nil)
+	_ TxRunner = (*Db)(nil)
+)
+
+func (t *Tx) Exec(query string, args ...any) (sql.Result, error) {
+	return t.ExecContext(context.Background(), query, args...)
+}
+
+func (t *Tx) Query(query string, args ...any) (*sql.Rows, error) {
+	return t.QueryContext(context.Background(), query, args...)
+}
+
+func (t *Tx) QueryRow(query string, args ...any) *sql.Row {
+	return t.QueryRowContext(context.Background(), query, args...)
+}
+
+func (t *Tx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	return hookedExec(ctx, t.hooks, t.Tx.ExecContext, query, args)
+}
+
+func (t *Tx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return hookedQuery(ctx, t.hooks, t.Tx.QueryContext, query, args)
+}
+
+func (t *Tx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	return hookedQueryRow(ctx, t.hooks, t.Tx.QueryRowContext, query, args)
+}
+
+// WithTx begins a transaction and runs fn inside of it.
+// the transaction is committed if fn returns nil and rolled back if fn returns an error or panics
+func (mdb *Db) WithTx(ctx context.Context, options *sql.TxOptions, fn func(tx DBOrTx) error) error {
+	tx, err := mdb.Begin(ctx, options)
+	if err != nil {
+		return err
+	}
+
+	return runTx(tx, fn, tx.Tx.Commit, tx.Tx.Rollback)
+}
+
+// Begin starts a transaction whose statements run through the hooks of the Db.
+// the caller has to commit or roll back tx.Tx, prefer WithTx where possible
+func (mdb *Db) Begin(ctx context.Context, options *sql.TxOptions) (*Tx, error) {
+	sqlTx, err := mdb.BeginTx(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction failed: %w", err)
+	}
+
+	return &Tx{Tx: sqlTx, DbType: mdb.DbType, hooks: mdb.Hooks}, nil
+}
+
+// WithTx runs fn inside a savepoint of the transaction. options are ignored since
+// a savepoint always shares the isolation level of its transaction
+func (t *Tx) WithTx(ctx context.Context, options *sql.TxOptions, fn func(tx DBOrTx) error) error {
+	dialect, err := GetDialect(t.DbType)
+	if err != nil {
+		return err
+	}
+
+	create, releaseQuery, rollbackQuery := dialect.SavepointQueries(fmt.Sprintf("gotools_sp_%d", t.depth+1))
+
+	if _, err := t.Tx.ExecContext(ctx, create); err != nil {
+		return fmt.Errorf("creating savepoint failed: %w", err)
+	}
+
+	nested := &Tx{Tx: t.Tx, DbType: t.DbType, depth: t.depth + 1, hooks: t.hooks}
+
+	release := func() error {
+		if releaseQuery == "" {
+			return nil
+		}
+		_, err := t.Tx.ExecContext(ctx, releaseQuery)
+		return err
+	}
+
+	rollback := func() error {
+		// the savepoint has to be rolled back even if ctx was canceled, otherwise the outer
+		// transaction would keep the changes of fn
+		_, err := t.Tx.ExecContext(context.WithoutCancel(ctx), rollbackQuery)
+		return err
+	}
+
+	return runTx(nested, fn, release, rollback)
+}
+
+// runTx calls fn with tx and commits or rolls back depending on its outcome. see util.RunTx
+func runTx(tx *Tx, fn func(tx DBOrTx) error, commit func() error, rollback func() error) error {
+	return util.RunTx(func() error { return fn(tx) }, commit, rollback)
+}
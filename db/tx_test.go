@@ -0,0 +1,134 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func setupTxTestTable(t *testing.T) *Db {
+	t.Helper()
+
+	d := getTestDb(t, DbConnectOptions{})
+	if _, err := d.Exec("CREATE TABLE items (name TEXT NOT NULL)"); err != nil {
+		t.Fatalf("Creating table failed: %v", err)
+	}
+
+	return d
+}
+
+func countItems(t *testing.T, d *Db) int {
+	t.Helper()
+
+	var cnt int
+	if err := d.QueryRow("SELECT COUNT(*) FROM items").Scan(&cnt); err != nil {
+		t.Fatalf("Counting items failed: %v", err)
+	}
+
+	return cnt
+}
+
+func TestWithTxCommits(t *testing.T) {
+	d := setupTxTestTable(t)
+
+	err := d.WithTx(context.Background(), nil, func(tx DBOrTx) error {
+		_, err := tx.Exec("INSERT INTO items (name) VALUES (?)", "first")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WithTx failed: %v", err)
+	}
+
+	if cnt := countItems(t, d); cnt != 1 {
+		t.Errorf("Expected 1 item, got %d", cnt)
+	}
+}
+
+func TestWithTxRollsBackOnError(t *testing.T) {
+	d := setupTxTestTable(t)
+	failure := errors.New("failure")
+
+	err := d.WithTx(context.Background(), nil, func(tx DBOrTx) error {
+		if _, err := tx.Exec("INSERT INTO items (name) VALUES (?)", "first"); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("Expected the callback error, got %v", err)
+	}
+
+	if cnt := countItems(t, d); cnt != 0 {
+		t.Errorf("Expected 0 items after rollback, got %d", cnt)
+	}
+}
+
+func TestWithTxRollsBackOnPanic(t *testing.T) {
+	d := setupTxTestTable(t)
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Expected panic to be re-raised")
+			}
+		}()
+
+		d.WithTx(context.Background(), nil, func(tx DBOrTx) error {
+			tx.Exec("INSERT INTO items (name) VALUES (?)", "first")
+			panic("boom")
+		})
+	}()
+
+	if cnt := countItems(t, d); cnt != 0 {
+		t.Errorf("Expected 0 items after rollback, got %d", cnt)
+	}
+}
+
+func TestWithTxNestedSavepoint(t *testing.T) {
+	d := setupTxTestTable(t)
+	ctx := context.Background()
+
+	err := d.WithTx(ctx, nil, func(tx DBOrTx) error {
+		if _, err := tx.Exec("INSERT INTO items (name) VALUES (?)", "outer"); err != nil {
+			return err
+		}
+
+		runner, ok := tx.(TxRunner)
+		if !ok {
+			t.Fatal("Expected tx to implement TxRunner")
+		}
+
+		nestedErr := runner.WithTx(ctx, nil, func(nested DBOrTx) error {
+			nested.Exec("INSERT INTO items (name) VALUES (?)", "inner")
+			return errors.New("discard inner")
+		})
+		if nestedErr == nil {
+			t.Error("Expected nested error to be returned")
+		}
+
+		return runner.WithTx(ctx, nil, func(nested DBOrTx) error {
+			_, err := nested.Exec("INSERT INTO items (name) VALUES (?)", "kept")
+			return err
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithTx failed: %v", err)
+	}
+
+	rows, err := d.Query("SELECT name FROM items ORDER BY name")
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		rows.Scan(&name)
+		names = append(names, name)
+	}
+
+	if len(names) != 2 || names[0] != "kept" || names[1] != "outer" {
+		t.Errorf("Expected [kept outer], got %v", names)
+	}
+}
@@ -0,0 +1,141 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// upserter is implemented by dialects which can insert rows or update them on a key conflict.
+// values is the row list of a VALUES clause as created by valuesList
+type upserter interface {
+	UpsertQuery(table string, columns []string, conflictColumns []string, updateColumns []string, values string) string
+}
+
+// Upsert inserts rows into table and updates updateColumns of rows which already exist.
+// rows are identified by conflictColumns, which need a primary key or unique constraint.
+// mysql ignores conflictColumns and uses any unique key of the table. without updateColumns
+// existing rows are left untouched.
+// rows with the same values in conflictColumns are reduced to the last one before writing, since
+// postgres and the MERGE of mssql reject a statement touching a row twice. the last row wins like
+// it would when the rows were upserted one by one.
+// all rows need the same columns. the returned count is the sum of the affected rows reported
+// by the driver, mysql counts an updated row twice
+func (mdb *Db) Upsert(ctx context.Context, table string, conflictColumns []string, updateColumns []string, rows []map[string]any) (int64, error) {
+	dialect, err := mdb.Dialect()
+	if err != nil {
+		return 0, err
+	}
+
+	inserter, ok := dialect.(upserter)
+	if !ok {
+		return 0, fmt.Errorf("upsert is not supported for database type %s", mdb.DbType)
+	}
+
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	columns := make([]string, 0, len(rows[0]))
+	for column := range rows[0] {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	if len(conflictColumns) == 0 {
+		return 0, fmt.Errorf("upsert into %s requires at least one conflict column", table)
+	}
+	for _, column := range append(append([]string{}, conflictColumns...), updateColumns...) {
+		if !slices.Contains(columns, column) {
+			return 0, fmt.Errorf("upsert into %s: column %s is missing in the rows", table, column)
+		}
+	}
+
+	values := make([][]any, len(rows))
+	for i, row := range rows {
+		if len(row) != len(columns) {
+			return 0, fmt.Errorf("upsert into %s: row %d has different columns than the first row", table, i+1)
+		}
+
+		values[i] = make([]any, len(columns))
+		for j, column := range columns {
+			value, ok := row[column]
+			if !ok {
+				return 0, fmt.Errorf("upsert into %s: row %d has no value for column %s", table, i+1, column)
+			}
+			values[i][j] = value
+		}
+	}
+
+	values = dedupeByConflictKey(values, columns, conflictColumns)
+
+	batchSize := dialectMaxPlaceholders(dialect) / len(columns)
+	if batchSize == 0 {
+		return 0, fmt.Errorf("upsert into %s: %d columns exceed the placeholder limit", table, len(columns))
+	}
+
+	var affected int64
+	err = mdb.WithTx(ctx, nil, func(tx DBOrTx) error {
+		for start := 0; start < len(values); start += batchSize {
+			batch := values[start:min(start+batchSize, len(values))]
+			valuesSql, args := valuesList(dialect.Placeholder(), batch)
+
+			result, err := tx.ExecContext(ctx, inserter.UpsertQuery(table, columns, conflictColumns, updateColumns, valuesSql), args...)
+			if err != nil {
+				return fmt.Errorf("upsert into %s failed: %w", table, err)
+			}
+
+			n, err := result.RowsAffected()
+			if err != nil {
+				return err
+			}
+			affected += n
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return affected, nil
+}
+
+// dedupeByConflictKey keeps the last row of every conflict key at its position
+func dedupeByConflictKey(values [][]any, columns []string, conflictColumns []string) [][]any {
+	indexes := make([]int, len(conflictColumns))
+	for i, column := range conflictColumns {
+		indexes[i] = slices.Index(columns, column)
+	}
+
+	last := make(map[string]int, len(values))
+	keys := make([]string, len(values))
+	for i, row := range values {
+		var sb strings.Builder
+		for _, index := range indexes {
+			value := row[index]
+			// int and int64 of the same number are the same key for the database
+			if normalized, err := normalizeValue(value); err == nil {
+				value = normalized
+			}
+			fmt.Fprintf(&sb, "%T:%v\x00", value, value)
+		}
+		keys[i] = sb.String()
+		last[keys[i]] = i
+	}
+
+	if len(last) == len(values) {
+		return values
+	}
+
+	deduped := make([][]any, 0, len(last))
+	for i, row := range values {
+		if last[keys[i]] == i {
+			deduped = append(deduped, row)
+		}
+	}
+
+	return deduped
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func setupUpsertTest(t *testing.T) *Db {
+	t.Helper()
+
+	d := getTestDb(t, DbConnectOptions{})
+	if _, err := d.Exec("CREATE TABLE stock (sku TEXT PRIMARY KEY, name TEXT NOT NULL, amount INTEGER NOT NULL)"); err != nil {
+		t.Fatalf("Creating table failed: %v", err)
+	}
+
+	return d
+}
+
+func TestUpsert(t *testing.T) {
+	d := setupUpsertTest(t)
+	ctx := context.Background()
+
+	rows := []map[string]any{
+		{"sku": "a", "name": "apple", "amount": 1},
+		{"sku": "b", "name": "banana", "amount": 2},
+	}
+	affected, err := d.Upsert(ctx, "stock", []string{"sku"}, []string{"amount"}, rows)
+	if err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if affected != 2 {
+		t.Errorf("Expected 2 affected rows, got %d", affected)
+	}
+
+	rows = []map[string]any{
+		{"sku": "a", "name": "renamed", "amount": 10},
+		{"sku": "c", "name": "cherry", "amount": 3},
+	}
+	if _, err := d.Upsert(ctx, "stock", []string{"sku"}, []string{"amount"}, rows); err != nil {
+		t.Fatalf("Second upsert failed: %v", err)
+	}
+
+	var name string
+	var amount int
+	if err := d.QueryRow("SELECT name, amount FROM stock WHERE sku = 'a'").Scan(&name, &amount); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if name != "apple" || amount != 10 {
+		t.Errorf("Expected only amount to be updated, got %s %d", name, amount)
+	}
+
+	// without update columns existing rows are kept
+	if _, err := d.Upsert(ctx, "stock", []string{"sku"}, nil, []map[string]any{{"sku": "a", "name": "x", "amount": 0}}); err != nil {
+		t.Fatalf("Upsert without update columns failed: %v", err)
+	}
+
+	var count int
+	d.QueryRow("SELECT COUNT(*) FROM stock WHERE amount = 10").Scan(&count)
+	if count != 1 {
+		t.Error("Expected the existing row to be untouched")
+	}
+}
+
+func TestUpsertDuplicateConflictKeys(t *testing.T) {
+	d := setupUpsertTest(t)
+
+	rows := []map[string]any{
+		{"sku": "a", "name": "apple", "amount": 1},
+		{"sku": "b", "name": "banana", "amount": 2},
+		{"sku": "a", "name": "apple", "amount": int64(5)},
+	}
+	affected, err := d.Upsert(context.Background(), "stock", []string{"sku"}, []string{"amount"}, rows)
+	if err != nil {
+		t.Fatalf("Upsert failed: %v", err)
+	}
+	if affected != 2 {
+		t.Errorf("Expected 2 affected rows, got %d", affected)
+	}
+
+	var amount int
+	if err := d.QueryRow("SELECT amount FROM stock WHERE sku = 'a'").Scan(&amount); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if amount != 5 {
+		t.Errorf("Expected the last row to win, got amount %d", amount)
+	}
+}
+
+func TestDedupeByConflictKey(t *testing.T) {
+	values := [][]any{{1, "a"}, {2, "b"}, {int64(1), "c"}, {3, "d"}}
+
+	deduped := dedupeByConflictKey(values, []string{"id", "name"}, []string{"id"})
+	expected := [][]any{{2, "b"}, {int64(1), "c"}, {3, "d"}}
+	if !reflect.DeepEqual(deduped, expected) {
+		t.Errorf("Expected %v, got %v", expected, deduped)
+	}
+}
+
+func TestUpsertValidation(t *testing.T) {
+	d := setupUpsertTest(t)
+	ctx := context.Background()
+
+	if _, err := d.Upsert(ctx, "stock", nil, nil, []map[string]any{{"sku": "a"}}); err == nil {
+		t.Error("Expected an error without conflict columns")
+	}
+
+	if _, err := d.Upsert(ctx, "stock", []string{"id"}, nil, []map[string]any{{"sku": "a"}}); err == nil {
+		t.Error("Expected an error for a conflict column missing in the rows")
+	}
+
+	rows := []map[string]any{{"sku": "a", "name": "x"}, {"sku": "b", "amount": 1}}
+	if _, err := d.Upsert(ctx, "stock", []string{"sku"}, nil, rows); err == nil {
+		t.Error("Expected an error for rows with different columns")
+	}
+}
+
+func TestUpsertQueries(t *testing.T) {
+	columns := []string{"amount", "sku"}
+	tests := map[string]string{
+		"postgres": `INSERT INTO "stock" ("amount", "sku") VALUES ($1, $2) ON CONFLICT ("sku") DO UPDATE SET "amount" = EXCLUDED."amount"`,
+		"mysql":    "INSERT INTO `stock` (`amount`, `sku`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `amount` = VALUES(`amount`)",
+		"mssql": "MERGE INTO [stock] WITH (HOLDLOCK) AS target USING (VALUES (@p1, @p2)) AS source ([amount], [sku]) ON target.[sku] = source.[sku]" +
+			" WHEN MATCHED THEN UPDATE SET target.[amount] = source.[amount]" +
+			" WHEN NOT MATCHED THEN INSERT ([amount], [sku]) VALUES (source.[amount], source.[sku]);",
+	}
+
+	for dbType, expected := range tests {
+		dialect, _ := GetDialect(dbType)
+		values, _ := valuesList(dialect.Placeholder(), [][]any{{1, "a"}})
+
+		query := dialect.(upserter).UpsertQuery("stock", columns, []string{"sku"}, []string{"amount"}, values)
+		if query != expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", dbType, expected, query)
+		}
+	}
+}
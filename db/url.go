@@ -0,0 +1,187 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maps the supported url schemes to the database type used by Connect
+var urlSchemes = map[string]string{
+	"mysql":      "mysql",
+	"postgres":   "postgres",
+	"postgresql": "postgres",
+	"sqlserver":  "mssql",
+	"mssql":      "mssql",
+	"sqlite":     "sqlite",
+	"sqlite3":    "sqlite",
+	"file":       "sqlite",
+}
+
+// default ports used when a url does not contain a port
+var defaultPorts = map[string]string{
+	"mysql":    "3306",
+	"postgres": "5432",
+	"mssql":    "1433",
+}
+
+// ConnectURL connects to the database described by a single url such as
+// postgres://user:pw@localhost:5432/app or sqlite:///var/lib/app.db
+func (d *Db) ConnectURL(rawURL string) error {
+	dbType, options, err := ParseURL(rawURL)
+	if err != nil {
+		return err
+	}
+
+	return d.Connect(dbType, options)
+}
+
+// ParseURL parses a database url into the database type and the options expected by Connect.
+// supported schemes are mysql, postgres/postgresql, sqlserver/mssql and sqlite/sqlite3/file
+func ParseURL(rawURL string) (string, DbConnectOptions, error) {
+	var options DbConnectOptions
+
+	if strings.TrimSpace(rawURL) == "" {
+		return "", options, errors.New("database url is empty")
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		// url.Error contains the whole url including the password
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return "", options, fmt.Errorf("invalid database url: %w", err)
+	}
+
+	if u.Scheme == "" {
+		return "", options, errors.New("invalid database url: missing scheme")
+	}
+
+	dbType, ok := urlSchemes[strings.ToLower(u.Scheme)]
+	if !ok {
+		return "", options, fmt.Errorf("unsupported database url scheme %q", u.Scheme)
+	}
+
+	if dbType == "sqlite" {
+		options.Database = sqlitePathFromURL(u)
+		if options.Database == "" {
+			return "", options, errors.New("invalid database url: missing sqlite file path")
+		}
+	} else {
+		options.Server = u.Hostname()
+		if options.Server == "" {
+			return "", options, errors.New("invalid database url: missing host")
+		}
+
+		options.Port = u.Port()
+		if options.Port == "" {
+			options.Port = defaultPorts[dbType]
+		}
+
+		if u.User != nil {
+			options.User = u.User.Username()
+			options.Pw, _ = u.User.Password()
+		}
+
+		options.Database = strings.TrimPrefix(u.Path, "/")
+	}
+
+	query := u.Query()
+	for alias, key := range urlParamAliases {
+		if query.Has(alias) && query.Has(key) && query.Get(alias) != query.Get(key) {
+			return "", options, fmt.Errorf("invalid database url: query parameters %q and %q conflict", key, alias)
+		}
+	}
+
+	// sorted keys make the result independent of the map order
+	keys := slices.Sorted(maps.Keys(query))
+	for _, key := range keys {
+		value := query.Get(key)
+
+		if err := applyURLParam(dbType, &options, key, value); err != nil {
+			return "", options, fmt.Errorf("invalid database url: %w", err)
+		}
+	}
+
+	if dbType != "sqlite" && options.Database == "" {
+		return "", options, errors.New("invalid database url: missing database name")
+	}
+
+	return dbType, options, nil
+}
+
+// sqlitePathFromURL supports sqlite:///abs/path.db, sqlite://relative/path.db and sqlite:path.db
+func sqlitePathFromURL(u *url.URL) string {
+	if u.Opaque != "" {
+		return u.Opaque
+	}
+
+	return u.Host + u.Path
+}
+
+// postgres style names accepted for the tls query parameters
+var urlParamAliases = map[string]string{
+	"sslmode":     "tls_mode",
+	"sslrootcert": "tls_ca",
+	"sslcert":     "tls_cert",
+	"sslkey":      "tls_key",
+}
+
+// applyURLParam sets the option belonging to the query parameter key
+func applyURLParam(dbType string, options *DbConnectOptions, key string, value string) error {
+	var err error
+
+	switch key {
+	case "database":
+		if dbType == "sqlite" {
+			return fmt.Errorf("query parameter %q is not supported for sqlite", key)
+		}
+		options.Database = value
+	case "protocol":
+		if dbType != "mysql" {
+			return fmt.Errorf("query parameter %q is only supported for mysql", key)
+		}
+		options.Protocol = value
+	case "max_open_conns":
+		options.MaxOpenConns, err = strconv.Atoi(value)
+	case "max_idle_conns":
+		options.MaxIdleConns, err = strconv.Atoi(value)
+	case "conn_max_lifetime":
+		options.ConnMaxLifetime, err = time.ParseDuration(value)
+	case "conn_max_idle_time":
+		options.ConnMaxIdleTime, err = time.ParseDuration(value)
+	case "connect_retries":
+		options.ConnectRetries, err = strconv.Atoi(value)
+	case "connect_timeout":
+		options.ConnectTimeout, err = time.ParseDuration(value)
+	case "tls_mode", "sslmode":
+		options.TLS.Mode = value
+	case "tls_ca", "sslrootcert":
+		options.TLS.CAFile = value
+	case "tls_cert", "sslcert":
+		options.TLS.CertFile = value
+	case "tls_key", "sslkey":
+		options.TLS.KeyFile = value
+	case "tls_server_name":
+		options.TLS.ServerName = value
+	default:
+		// everything else is passed on to the driver
+		if options.Params == nil {
+			options.Params = map[string]string{}
+		}
+		options.Params[key] = value
+	}
+
+	if err != nil {
+		return fmt.Errorf("query parameter %q: %w", key, err)
+	}
+
+	return nil
+}
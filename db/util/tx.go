@@ -0,0 +1,43 @@
+package util
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// WithTx runs fn inside a transaction of conn. the transaction is committed if fn returns nil
+// and rolled back if fn returns an error or panics. the connectors use it for their migration
+// runners since they cannot use db.WithTx
+func WithTx(ctx context.Context, conn *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction failed: %w", err)
+	}
+
+	return RunTx(func() error { return fn(tx) }, tx.Commit, tx.Rollback)
+}
+
+// RunTx calls fn and commits or rolls back depending on its outcome. panics are re-raised after the rollback.
+// commit and rollback are passed in so savepoints can be handled the same way as transactions
+func RunTx(fn func() error, commit func() error, rollback func() error) error {
+	defer func() {
+		if p := recover(); p != nil {
+			rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(); err != nil {
+		if rollbackErr := rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+
+	if err := commit(); err != nil {
+		return fmt.Errorf("committing transaction failed: %w", err)
+	}
+
+	return nil
+}
@@ -199,3 +199,27 @@ func (z *Zip) Unzip(destination string) error {
 
 	return nil
 }
+
+// Writer streams files into a zip archive. unlike Zip the contents are not kept in memory
+type Writer struct {
+	writer *zip.Writer
+}
+
+// NewWriter returns a Writer which writes the archive to w. Close has to be called to finish the archive
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{writer: zip.NewWriter(w)}
+}
+
+// Create adds the file fileName to the archive. its contents have to be written to the returned
+// writer before the next call to Create or Close
+func (w *Writer) Create(fileName string) (io.Writer, error) {
+	if strings.TrimSpace(fileName) == "" {
+		return nil, errors.New("parameter fileName is empty")
+	}
+	return w.writer.Create(fileName)
+}
+
+// Close finishes the archive. it does not close the underlying writer
+func (w *Writer) Close() error {
+	return w.writer.Close()
+}